@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+)
+
+// runAdd implements "gen-code add <kind> <name>".
+func runAdd(args []string) {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: gen-code add [flags] <kind> <name>\n\nAdds a piece to a project generated by gen-code, following its layout.\n\nKinds:")
+		for _, k := range matrix.GeneratorKinds {
+			fmt.Fprintf(out, "  %-10s %s\n", k, k.Description())
+		}
+		fmt.Fprintln(out, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", ".", "project directory")
+	dryRun := fs.Bool("dry-run", false, "print what would change, with diffs, without writing anything")
+	templates := fs.String("templates", matrix.DefaultPackDir(), "directory containing user template packs")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	kind, err := matrix.ParseGeneratorKind(fs.Arg(0))
+	if err != nil {
+		fail(2, err)
+	}
+
+	// Packs are loaded so that projects generated from one are recognised.
+	warnings, err := matrix.LoadPacks(*templates)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: %s\n", w)
+	}
+
+	report, err := scaffold.Add(scaffold.Options{OutputDir: *dir, DryRun: *dryRun}, kind, fs.Arg(1))
+	if err == nil {
+		if *dryRun {
+			fmt.Print(report.String())
+		} else {
+			fmt.Print(report.Tree() + "\n" + report.Summary() + "\n")
+		}
+	}
+	if err != nil {
+		var rollback *scaffold.RollbackError
+		if errors.As(err, &rollback) {
+			err = fmt.Errorf("%w\n%s", err, strings.TrimRight(rollback.Details(), "\n"))
+		}
+		fail(1, err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gen-code/internal/capture"
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+)
+
+// runCapture implements "gen-code capture <dir>".
+func runCapture(args []string) {
+	fs := flag.NewFlagSet("capture", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gen-code capture [flags] <dir>\n\nTurns an existing project into a template pack that gen-code offers as a\nnew framework.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	var opts capture.Options
+	fs.StringVar(&opts.Name, "name", "", "pack name (defaults to the directory name)")
+	fs.StringVar(&opts.Version, "version", "", "pack version (defaults to 0.1.0)")
+	fs.StringVar(&opts.Description, "description", "", "description shown in the wizard")
+	lang := fs.String("language", "", "language of the project (detected when left out)")
+	fw := fs.String("framework", "", "name of the framework the pack adds (required)")
+	types := fs.String("types", "", "comma-separated project types to offer the pack for (defaults to all)")
+	complexities := fs.String("complexities", "", "comma-separated complexities to offer the pack for (defaults to all)")
+	fs.StringVar(&opts.ModulePath, "module", "", "module path to replace with {{.ModulePath}} (defaults to the one in go.mod)")
+	fs.StringVar(&opts.AppName, "app-name", "", "app name to replace with {{.AppName}}, together with its kebab-case and snake_case forms")
+	fs.Func("replace", "literal=expression to replace with {{expression}}, e.g. 8080=.Port (repeatable)", func(s string) error {
+		r, err := capture.ParseReplacement(s)
+		opts.Replace = append(opts.Replace, r)
+		return err
+	})
+	fs.Func("exclude", ".gitignore-style pattern of files to leave out (repeatable)", func(s string) error {
+		opts.Exclude = append(opts.Exclude, s)
+		return nil
+	})
+	out := fs.String("out", "", "directory to write the pack to (defaults to <templates>/<name>)")
+	templates := fs.String("templates", matrix.DefaultPackDir(), "directory containing user template packs")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts.Language = matrix.Language(*lang)
+	opts.Framework = matrix.Framework(*fw)
+	for _, pt := range splitList(*types) {
+		opts.ProjectTypes = append(opts.ProjectTypes, matrix.ProjectType(pt))
+	}
+	for _, c := range splitList(*complexities) {
+		opts.Complexities = append(opts.Complexities, matrix.Complexity(c))
+	}
+
+	// Installed packs count as known frameworks too.
+	warnings, err := matrix.LoadPacks(*templates)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: %s\n", w)
+	}
+
+	res, err := capture.Capture(fs.Arg(0), opts)
+	if err != nil {
+		fail(1, err)
+	}
+
+	dir := *out
+	if dir == "" {
+		if *templates == "" {
+			fail(2, fmt.Errorf("no template directory to install into; pass -out"))
+		}
+		dir = filepath.Join(*templates, res.Pack.Name)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		fail(1, fmt.Errorf("%s already exists and is not empty", dir))
+	}
+	if err := scaffold.Dir(dir).Write(res.Files); err != nil {
+		fail(1, err)
+	}
+
+	for _, s := range res.Skipped {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: skipped %s: not a regular text file\n", s)
+	}
+	for _, u := range res.Unused {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: %q does not occur in the project\n", u)
+	}
+	fmt.Printf("Captured %d files into %s: pack %s adds %s %s.\n", res.Captured, dir, res.Pack.Name, res.Pack.Language, res.Pack.Framework)
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
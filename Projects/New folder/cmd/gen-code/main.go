@@ -1,17 +1,304 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"gen-code/internal/answers"
+	"gen-code/internal/matrix"
+	"gen-code/internal/presets"
+	"gen-code/internal/scaffold"
 	"gen-code/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// commands are the subcommands; without one, gen-code generates a project.
+var commands = map[string]func(args []string){
+	"add":     runAdd,
+	"upgrade": runUpgrade,
+	"serve":   runServe,
+	"capture": runCapture,
+}
+
 func main() {
-	p := tea.NewProgram(tui.InitialModel())
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
+	answersFile := flag.String("answers", "", "path to a YAML or JSON answers file (runs without the TUI)")
+	name := flag.String("name", "", "application name")
+	lang := flag.String("lang", "", "language: Go, JavaScript, Python")
+	fw := flag.String("framework", "", "framework for the chosen language")
+	pt := flag.String("type", "", "project type: web, cli, backend")
+	comp := flag.String("complexity", "", "complexity: minimal, standard, enterprise")
+	out := flag.String("out", "", "output path; a .zip, .tar.gz or .tgz path writes an archive instead of a directory")
+	module := flag.String("module", "", "Go module path (defaults to the app name)")
+	port := flag.Int("port", 0, "HTTP port baked into the generated server (defaults per framework)")
+	addons := flag.String("addons", "", "comma-separated add-ons: sqlite, postgres, mongo, auth, docker, tests, lint, openapi, readme, gitignore, editorconfig, license")
+	contract := flag.String("openapi", "", "OpenAPI 3 document (YAML or JSON) to generate routes, types and handler stubs from")
+	entities := flag.String("entities", "", "entities file (YAML or JSON) to generate models, SQL migrations, repositories, services and handlers from (Go only)")
+	var vars []string
+	flag.Func("var", "answer to a stack prompt as name=value, e.g. node_version=22 (repeatable)", func(v string) error {
+		if name, _, ok := strings.Cut(v, "="); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("%q must look like name=value", v)
+		}
+		vars = append(vars, v)
+		return nil
+	})
+	dryRun := flag.Bool("dry-run", false, "print the planned file tree and diffs without writing anything")
+	skipHooks := flag.Bool("skip-hooks", false, "do not run post-generation hooks such as git init, go mod tidy and npm install (e.g. when offline)")
+	onConflict := flag.String("on-conflict", string(scaffold.PolicyAbort), "what to do with existing files: abort, skip, overwrite, new")
+	templates := flag.String("templates", matrix.DefaultPackDir(), "directory containing user template packs")
+	configPath := flag.String("config", presets.DefaultPath(), "config file holding presets and recent projects")
+	preset := flag.String("preset", "", "start from a saved preset (runs without the TUI)")
+	recent := flag.Int("recent", 0, "start from the n-th most recently generated project (runs without the TUI)")
+	savePreset := flag.String("save-preset", "", "save the stack of this run as a preset under the given name")
+	listPresets := flag.Bool("list-presets", false, "print saved presets and recent projects, then exit")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gen-code [flags]\n       gen-code add [flags] <kind> <name>\n       gen-code upgrade [flags] [project-dir]\n       gen-code serve [flags]\n       gen-code capture [flags] <dir>\n\nWithout answer flags gen-code starts the interactive wizard.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	warnings, err := matrix.LoadPacks(*templates)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	store, err := presets.Load(*configPath)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	if *listPresets {
+		printPresets(store)
+		return
+	}
+
+	// Any answer supplied up front means we're running from a script.
+	if headless() {
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "gen-code: warning: %s\n", w)
+		}
+
+		if *answersFile != "" && (*preset != "" || *recent != 0) || *preset != "" && *recent != 0 {
+			fail(2, errors.New("-answers, -preset and -recent each supply a starting point; pick one"))
+		}
+
+		a := answers.Answers{}
+		if *preset != "" {
+			p, ok := store.Preset(*preset)
+			if !ok {
+				fail(2, fmt.Errorf("no preset named %q in %s (see -list-presets)", *preset, store.Path()))
+			}
+			a = p.Apply(a)
+		}
+		if *recent != 0 {
+			r, err := store.RecentProject(*recent)
+			if err != nil {
+				fail(2, err)
+			}
+			a = r.Answers
+		}
+		if *answersFile != "" {
+			loaded, err := answers.Load(*answersFile)
+			if err != nil {
+				fail(2, err)
+			}
+			a = loaded
+		}
+
+		// Flags win over the file so one answers file can be reused with tweaks.
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				a.AppName = *name
+			case "lang":
+				a.Language = matrix.Language(*lang)
+			case "framework":
+				a.Framework = matrix.Framework(*fw)
+			case "type":
+				a.ProjectType = matrix.ProjectType(*pt)
+			case "complexity":
+				a.Complexity = matrix.Complexity(*comp)
+			case "out":
+				a.Output = *out
+			case "module":
+				a.ModulePath = *module
+			case "port":
+				a.Port = *port
+			case "openapi":
+				a.OpenAPI = *contract
+			case "entities":
+				a.Entities = *entities
+			case "var":
+				for _, v := range vars {
+					name, value, _ := strings.Cut(v, "=")
+					if err := a.SetVar(strings.TrimSpace(name), value); err != nil {
+						fail(2, err)
+					}
+				}
+			case "addons":
+				a.Addons = nil
+				for _, name := range strings.Split(*addons, ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						a.Addons = append(a.Addons, matrix.Addon(name))
+					}
+				}
+			}
+		})
+
+		policy, err := scaffold.ParsePolicy(*onConflict)
+		if err != nil {
+			fail(2, err)
+		}
+
+		runHeadless(a, scaffold.Options{Policy: policy, DryRun: *dryRun}, store, *savePreset, *skipHooks)
+		return
+	}
+
+	if *savePreset != "" {
+		fail(2, errors.New("-save-preset needs answers on the command line; in the wizard press p on the last screen"))
+	}
+
+	p := tea.NewProgram(tui.InitialModel(tui.Options{Warnings: warnings, Store: store, SkipHooks: *skipHooks}))
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
 }
+
+// answerFlags are the flags that supply wizard answers. Passing any of them
+// means we're running from a script; settings such as -templates alone
+// still start the TUI.
+var answerFlags = map[string]bool{
+	"answers": true, "name": true, "lang": true, "framework": true, "type": true,
+	"complexity": true, "out": true, "module": true, "port": true, "addons": true,
+	"openapi": true, "entities": true, "var": true, "preset": true, "recent": true,
+}
+
+func headless() bool {
+	answered := false
+	flag.Visit(func(f *flag.Flag) {
+		if answerFlags[f.Name] {
+			answered = true
+		}
+	})
+	return answered
+}
+
+func runHeadless(a answers.Answers, opts scaffold.Options, store *presets.Store, savePreset string, skipHooks bool) {
+	a, err := a.Validate()
+	if err != nil {
+		fail(2, fmt.Errorf("invalid answers:\n%w", err))
+	}
+	opts.OutputDir = a.Output
+	if scaffold.ArchiveFormat(a.Output) != "" {
+		if opts.Sink, err = scaffold.ArchiveFile(a.Output); err != nil {
+			fail(2, err)
+		}
+	} else if err := scaffold.CheckOutputDir(a.Output); err != nil {
+		fail(2, err)
+	}
+
+	report, err := scaffold.Scaffold(a.Spec(), opts)
+
+	var (
+		conflict *scaffold.ConflictError
+		rollback *scaffold.RollbackError
+	)
+	switch {
+	case opts.DryRun && report != nil:
+		fmt.Print(report.String())
+	case err == nil || errors.As(err, &conflict):
+		fmt.Print(report.Tree() + "\n" + report.Summary() + "\n")
+	}
+
+	if err != nil {
+		if conflict != nil {
+			err = fmt.Errorf("%w\nre-run with -dry-run to see the diffs, or pick -on-conflict skip|overwrite|new", err)
+		}
+		if errors.As(err, &rollback) {
+			err = fmt.Errorf("%w\n%s", err, strings.TrimRight(rollback.Details(), "\n"))
+		}
+		fail(1, err)
+	}
+
+	if savePreset != "" {
+		if err := store.SavePreset(presets.FromAnswers(savePreset, a)); err != nil {
+			fail(2, err)
+		}
+	}
+	if !opts.DryRun {
+		fmt.Printf("Scaffolded %s (%s/%s) into %s\n", a.AppName, a.Language, a.Framework, a.Output)
+		store.Remember(a, time.Now())
+	}
+	if savePreset != "" || !opts.DryRun {
+		if err := store.Save(); err != nil {
+			// The project is written; a lost history entry is not worth failing for.
+			if savePreset != "" {
+				fail(1, fmt.Errorf("could not save preset %s: %w", savePreset, err))
+			}
+			fmt.Fprintf(os.Stderr, "gen-code: warning: could not record the project in %s: %v\n", store.Path(), err)
+		} else if savePreset != "" {
+			fmt.Printf("Saved preset %s (replay with: gen-code -preset %s -name NAME -out PATH)\n", savePreset, savePreset)
+		}
+	}
+
+	// Archives have no directory to run commands in.
+	if !opts.DryRun && opts.Sink == nil && !skipHooks {
+		runHooks(a.Output, matrix.DefaultRegistry().Hooks(a.Spec()))
+	}
+}
+
+// runHooks runs the post-generation hooks one after another, streaming
+// their output. A failed hook does not stop the others; the run fails at
+// the end, since the project itself is complete.
+func runHooks(dir string, hooks []matrix.Hook) {
+	var failed []string
+	for _, h := range hooks {
+		fmt.Printf("==> %s\n", h.Name)
+		err := scaffold.RunHook(context.Background(), dir, h, os.Stdout)
+		switch {
+		case errors.Is(err, scaffold.ErrHookSkipped):
+			fmt.Println(err)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "gen-code: %v\n", err)
+			failed = append(failed, h.Name)
+		}
+	}
+	if len(failed) > 0 {
+		fail(1, fmt.Errorf("%d of %d hook(s) failed: %s; the project is complete, so run them by hand once the cause is fixed, or pass -skip-hooks", len(failed), len(hooks), strings.Join(failed, ", ")))
+	}
+}
+
+func printPresets(store *presets.Store) {
+	if len(store.Presets) == 0 && len(store.Recent) == 0 {
+		fmt.Printf("No presets or recent projects in %s\n", store.Path())
+		return
+	}
+	if len(store.Presets) > 0 {
+		fmt.Println("Presets (use with -preset NAME):")
+		for _, p := range store.Presets {
+			fmt.Printf("  %-20s %s\n", p.Name, p.Summary())
+		}
+	}
+	if len(store.Recent) > 0 {
+		fmt.Println("Recent projects (use with -recent N):")
+		for i, r := range store.Recent {
+			fmt.Printf("  %2d  %-20s %s  %s\n", i+1, r.Answers.AppName, r.Answers.Output, r.GeneratedAt.Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func fail(code int, err error) {
+	fmt.Fprintf(os.Stderr, "gen-code: %v\n", err)
+	os.Exit(code)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the tests run the test binary as gen-code itself.
+func TestMain(m *testing.M) {
+	if os.Getenv("GEN_CODE_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// genCode runs gen-code headless with args and returns its output and exit
+// status. Packs and the config file are kept out of the user's home.
+func genCode(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	args = append([]string{"-templates", filepath.Join(dir, "packs"), "-config", filepath.Join(dir, "config.yaml"), "-skip-hooks"}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GEN_CODE_RUN_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return string(out), exit.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestHeadlessRejectsBadModulePath(t *testing.T) {
+	for _, module := range []string{`foo bar"`, "../escape", "a/./b"} {
+		out, code := genCode(t, "-name", "demo", "-lang", "go", "-framework", "gin", "-type", "cli", "-complexity", "minimal",
+			"-module", module, "-out", filepath.Join(t.TempDir(), "demo"))
+		if code != 2 || !strings.Contains(out, "invalid answers") || !strings.Contains(out, "Module Path") {
+			t.Errorf("-module %q: exit %d\n%s", module, code, out)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "demo")
+	out, code := genCode(t, "-name", "demo", "-lang", "go", "-framework", "gin", "-type", "cli", "-complexity", "minimal",
+		"-module", "github.com/acme/demo", "-out", dir)
+	if mod, _ := os.ReadFile(filepath.Join(dir, "go.mod")); code != 0 || !strings.Contains(string(mod), "module github.com/acme/demo") {
+		t.Errorf("valid module path: exit %d\n%s", code, out)
+	}
+}
+
+func TestHeadlessFlagsWinOverAnswersFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "answers.yaml")
+	data := "app_name: from-file\nlanguage: Go\nframework: Gin\nproject_type: Backend Service\ncomplexity: minimal\noutput: " + filepath.Join(dir, "from-file") + "\n"
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "from-flags")
+	got, code := genCode(t, "-answers", file, "-name", "from-flags", "-type", "cli", "-out", out)
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, got)
+	}
+	if want := "Scaffolded from-flags (Go/Gin) into " + out; !strings.Contains(got, want) {
+		t.Errorf("output does not say %q:\n%s", want, got)
+	}
+	manifest, err := os.ReadFile(filepath.Join(out, ".gencode.json"))
+	if err != nil || !strings.Contains(string(manifest), `"CLI Tool"`) {
+		t.Errorf("manifest does not record the -type flag: %v\n%s", err, manifest)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "from-file")); err == nil {
+		t.Error("the answers file's output was used")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/server"
+)
+
+// runServe implements "gen-code serve".
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: gen-code serve [flags]\n\nServes gen-code over HTTP:\n  GET  /api/options   languages, frameworks and options as JSON\n  POST /api/generate  answers as JSON in, the project as a zip out\n  GET  /health\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", ":8080", "address to listen on")
+	maxBody := fs.Int64("max-body", 64<<10, "largest generation request accepted, in bytes")
+	maxConcurrent := fs.Int("max-concurrent", 4, "projects generated at once; further requests get 503")
+	templates := fs.String("templates", matrix.DefaultPackDir(), "directory containing user template packs")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	warnings, err := matrix.LoadPacks(*templates)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: %s\n", w)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(server.Options{MaxBodyBytes: *maxBody, MaxConcurrent: *maxConcurrent}).Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Downloads in flight are allowed to finish before the process exits.
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "gen-code: serving on %s\n", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fail(1, err)
+	}
+	<-drained
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+)
+
+// runUpgrade implements "gen-code upgrade [dir]".
+func runUpgrade(args []string) {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gen-code upgrade [flags] [project-dir]\n\nRe-renders a generated project with the current templates and merges the changes into local edits.")
+		fs.PrintDefaults()
+	}
+	dryRun := fs.Bool("dry-run", false, "print what would change, with diffs, without writing anything")
+	templates := fs.String("templates", matrix.DefaultPackDir(), "directory containing user template packs")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	warnings, err := matrix.LoadPacks(*templates)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "gen-code: warning: %s\n", w)
+	}
+
+	manifest, err := scaffold.LoadManifest(dir)
+	if err != nil {
+		fail(2, err)
+	}
+	_, version := matrix.TemplateSource(manifest.Spec)
+	fmt.Printf("Upgrading %s from %s templates %s to %s\n\n", dir, manifest.TemplateSource, manifest.TemplateVersion, version)
+
+	report, err := scaffold.Upgrade(scaffold.Options{OutputDir: dir, DryRun: *dryRun})
+	if report != nil {
+		if *dryRun {
+			fmt.Print(report.String())
+		} else {
+			fmt.Print(report.Tree() + "\n" + report.Summary() + "\n")
+		}
+	}
+	if err != nil {
+		var rollback *scaffold.RollbackError
+		if errors.As(err, &rollback) {
+			err = fmt.Errorf("%w\n%s", err, strings.TrimRight(rollback.Details(), "\n"))
+		}
+		fail(1, err)
+	}
+
+	var conflicted []string
+	for _, e := range report.Entries {
+		if e.Action == scaffold.ActionMergeConflict {
+			conflicted = append(conflicted, e.Path)
+		}
+	}
+	if len(conflicted) > 0 && !*dryRun {
+		fail(1, fmt.Errorf("%d file(s) need a manual merge, look for <<<<<<< markers: %s", len(conflicted), strings.Join(conflicted, ", ")))
+	}
+}
@@ -0,0 +1,242 @@
+package answers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/openapi"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers is everything the wizard asks for, in a form that can be written
+// to disk and replayed later without the TUI.
+type Answers struct {
+	AppName     string             `json:"app_name" yaml:"app_name"`
+	Language    matrix.Language    `json:"language" yaml:"language"`
+	Framework   matrix.Framework   `json:"framework" yaml:"framework"`
+	ProjectType matrix.ProjectType `json:"project_type" yaml:"project_type"`
+	Complexity  matrix.Complexity  `json:"complexity" yaml:"complexity"`
+	Output      string             `json:"output" yaml:"output"`
+
+	// Optional template variables; matrix.Spec.WithDefaults fills the gaps.
+	ModulePath string `json:"module_path,omitempty" yaml:"module_path,omitempty"`
+	Port       int    `json:"port,omitempty" yaml:"port,omitempty"`
+
+	Addons []matrix.Addon `json:"addons,omitempty" yaml:"addons,omitempty"`
+
+	// Vars answers the extra prompts of the stack by name; see
+	// matrix.Registry.Prompts. The port and module_path prompts fill the
+	// fields above instead.
+	Vars map[string]string `json:"vars,omitempty" yaml:"vars,omitempty"`
+
+	// OpenAPI is the path of an OpenAPI 3 document to generate handlers
+	// from. Validate loads it.
+	OpenAPI  string `json:"openapi,omitempty" yaml:"openapi,omitempty"`
+	contract *openapi.API
+
+	// Entities is the path of an entities file to generate CRUD layers
+	// from. Validate loads it.
+	Entities string `json:"entities,omitempty" yaml:"entities,omitempty"`
+	entities []matrix.Entity
+}
+
+func (a Answers) Spec() matrix.Spec {
+	return matrix.Spec{
+		AppName:     a.AppName,
+		Language:    a.Language,
+		Framework:   a.Framework,
+		ProjectType: a.ProjectType,
+		Complexity:  a.Complexity,
+		ModulePath:  a.ModulePath,
+		Port:        a.Port,
+		Addons:      a.Addons,
+		Vars:        a.Vars,
+		Contract:    a.contract,
+		Entities:    a.entities,
+	}
+}
+
+// SetVar answers the prompt called name.
+func (a *Answers) SetVar(name, value string) error {
+	switch name {
+	case matrix.VarPort:
+		port, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("port must be a whole number, not %q", value)
+		}
+		a.Port = port
+	case matrix.VarModulePath:
+		a.ModulePath = strings.TrimSpace(value)
+	default:
+		if a.Vars == nil {
+			a.Vars = map[string]string{}
+		}
+		a.Vars[name] = value
+	}
+	return nil
+}
+
+// Load reads an answers file. Files ending in .json are decoded as JSON,
+// everything else as YAML.
+func Load(path string) (Answers, error) {
+	var a Answers
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return a, fmt.Errorf("failed to read answers file: %w", err)
+	}
+
+	if isJSON(path) {
+		err = json.Unmarshal(data, &a)
+	} else {
+		err = yaml.Unmarshal(data, &a)
+	}
+	if err != nil {
+		return a, fmt.Errorf("failed to parse answers file %s: %w", path, err)
+	}
+
+	return a, nil
+}
+
+// Save writes the answers to path, picking the format from the extension.
+func (a Answers) Save(path string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if isJSON(path) {
+		data, err = json.MarshalIndent(a, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(a)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode answers: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write answers file: %w", err)
+	}
+	return nil
+}
+
+// Validate checks every field against the matrix and returns a copy with the
+// values normalised to their canonical constants. All problems are reported
+// at once so a script author can fix them in one pass.
+func (a Answers) Validate() (Answers, error) {
+	var errs []error
+	reg := matrix.DefaultRegistry()
+
+	lang, err := reg.ParseLanguage(string(a.Language))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	a.Language = lang
+
+	// With an unknown language only the rules shared by all languages apply.
+	a.AppName = strings.TrimSpace(a.AppName)
+	if err := matrix.ValidateAppName(lang, a.AppName); err != nil {
+		errs = append(errs, err)
+	}
+
+	if lang != "" {
+		fw, err := reg.ParseFramework(lang, string(a.Framework))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		a.Framework = fw
+	}
+
+	pt, err := reg.ParseProjectType(string(a.ProjectType))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	a.ProjectType = pt
+
+	c, err := reg.ParseComplexity(string(a.Complexity))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	a.Complexity = c
+
+	a.Addons = slices.Clone(a.Addons)
+	for i, name := range a.Addons {
+		addon, err := reg.ParseAddon(string(name))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		a.Addons[i] = addon
+	}
+
+	vars := a.Vars
+	a.Vars = nil
+	for name, value := range vars {
+		if err := a.SetVar(name, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	a.contract = nil
+	if a.OpenAPI != "" {
+		api, err := openapi.Load(a.OpenAPI)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		a.contract = api
+	}
+
+	a.entities = nil
+	if a.Entities != "" {
+		entities, err := matrix.LoadEntities(a.Entities)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		a.entities = entities
+	}
+
+	// Only check the combination once every part of it is known. Its port
+	// and module path prompts, if any, check those fields.
+	portChecked, moduleChecked := false, false
+	if a.Framework != "" && pt != "" && c != "" {
+		if err := reg.Validate(a.Spec()); err != nil {
+			errs = append(errs, err)
+		}
+		for _, p := range reg.Prompts(a.Spec()) {
+			portChecked = portChecked || p.Name == matrix.VarPort
+			moduleChecked = moduleChecked || p.Name == matrix.VarModulePath
+			if value, ok := a.Vars[p.Name]; ok {
+				if value, err := p.Parse(value); err == nil {
+					a.Vars[p.Name] = value
+				}
+			}
+		}
+	}
+
+	if a.ModulePath = strings.TrimSpace(a.ModulePath); a.ModulePath != "" && !moduleChecked {
+		if err := matrix.ValidateModulePath(a.ModulePath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !portChecked && (a.Port < 0 || a.Port > 65535) {
+		errs = append(errs, fmt.Errorf("port %d is out of range (1-65535)", a.Port))
+	}
+
+	if strings.TrimSpace(a.Output) == "" {
+		a.Output = "."
+	}
+
+	return a, errors.Join(errs...)
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
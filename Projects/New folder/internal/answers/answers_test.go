@@ -0,0 +1,102 @@
+package answers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in   Answers
+		want Answers
+	}{
+		{
+			Answers{AppName: " shop ", Language: "golang", Framework: "gin", ProjectType: "backend", Complexity: "standard", Addons: []matrix.Addon{"sqlite"}},
+			Answers{AppName: "shop", Language: matrix.Go, Framework: matrix.Gin, ProjectType: matrix.Backend, Complexity: matrix.Standard, Output: ".", Addons: []matrix.Addon{matrix.SQLite}},
+		},
+		{
+			Answers{AppName: "tool", Language: "node", Framework: "EXPRESS", ProjectType: "CLI Tool", Complexity: "Minimal (MVP)", Output: "out"},
+			Answers{AppName: "tool", Language: matrix.JS, Framework: matrix.Express, ProjectType: matrix.CLI, Complexity: matrix.Minimal, Output: "out"},
+		},
+		{
+			Answers{AppName: "site", Language: "py", Framework: "django", ProjectType: "web", Complexity: "enterprise", Port: 65535},
+			Answers{AppName: "site", Language: matrix.Python, Framework: matrix.Django, ProjectType: matrix.WebApp, Complexity: matrix.Enterprise, Output: ".", Port: 65535},
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.Validate()
+		if err != nil {
+			t.Errorf("%+v: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got %+v\nwant %+v", got, tt.want)
+		}
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	tests := []struct {
+		in   Answers
+		want []string
+	}{
+		// Every problem is reported at once.
+		{Answers{}, []string{"app name is required", "unknown language", "unknown project type", "unknown complexity"}},
+		{Answers{AppName: "x", Language: "go", Framework: "flask", ProjectType: "cli", Complexity: "minimal", Addons: []matrix.Addon{"redis"}},
+			[]string{`unknown framework "flask" for Go`, `unknown add-on "redis"`}},
+		{Answers{AppName: "x", Language: "python", Framework: "django", ProjectType: "cli", Complexity: "minimal"},
+			[]string{"Django does not support CLI Tool projects"}},
+		{Answers{AppName: "x", Language: "go", Framework: "gin", ProjectType: "backend", Complexity: "minimal", Port: 65536},
+			[]string{"HTTP Port 65536 is out of range (1-65535)"}},
+		// Without a port prompt the plain range still applies.
+		{Answers{AppName: "x", Language: "go", Framework: "gin", ProjectType: "cli", Complexity: "minimal", Port: -1},
+			[]string{"port -1 is out of range"}},
+		{Answers{AppName: "x", Language: "go", Port: 70000},
+			[]string{"unknown project type", "port 70000 is out of range"}},
+	}
+	for _, tt := range tests {
+		_, err := tt.in.Validate()
+		if err == nil {
+			t.Errorf("%+v: accepted", tt.in)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%+v: got %v, want %q", tt.in, err, want)
+			}
+		}
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	a := Answers{
+		AppName: "shop", Language: matrix.Go, Framework: matrix.Gin, ProjectType: matrix.Backend, Complexity: matrix.Standard,
+		Output: "shop", ModulePath: "github.com/acme/shop", Port: 9090,
+		Addons: []matrix.Addon{matrix.SQLite, matrix.Docker}, Vars: map[string]string{"db_name": "shop"},
+	}
+	for _, name := range []string{"answers.json", "answers.yaml", "answers.JSON"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := a.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(path); strings.HasPrefix(string(data), "{") != strings.EqualFold(filepath.Ext(name), ".json") {
+			t.Errorf("%s was written as:\n%s", name, data)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, a) {
+			t.Errorf("%s: got %+v, want %+v", name, got, a)
+		}
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
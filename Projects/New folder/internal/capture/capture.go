@@ -0,0 +1,375 @@
+// Package capture turns an existing project into a template pack, so that
+// a reference project can be generated again under new names without
+// writing its templates by hand.
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing/fstest"
+	"unicode/utf8"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	// Name, Version and Description go into the pack manifest. The name
+	// defaults to the base name of the captured directory.
+	Name        string
+	Version     string
+	Description string
+
+	// Language defaults to the one recorded in .gencode.json, or the one
+	// go.mod, package.json or requirements.txt points to. Framework names
+	// the new framework the pack adds and is required.
+	Language  matrix.Language
+	Framework matrix.Framework
+
+	// ProjectTypes and Complexities restrict what the pack is offered for.
+	// They default to what .gencode.json records, or to everything.
+	ProjectTypes []matrix.ProjectType
+	Complexities []matrix.Complexity
+
+	// ModulePath is replaced by {{.ModulePath}}. It defaults to the module
+	// in go.mod.
+	ModulePath string
+	// AppName is replaced by {{.AppName}}, and its kebab-case and
+	// snake_case forms by the matching helpers. It defaults to the name in
+	// .gencode.json.
+	AppName string
+	// Replace lists further literals to turn into template actions.
+	Replace []Replacement
+
+	// Exclude holds extra .gitignore-style patterns to leave out.
+	Exclude []string
+}
+
+// Replacement turns every occurrence of Literal into the template action
+// {{Expr}}, e.g. "8080" into {{.Port}}.
+type Replacement struct {
+	Literal string
+	Expr    string
+}
+
+// ParseReplacement reads a replacement written as literal=expr.
+func ParseReplacement(s string) (Replacement, error) {
+	literal, expr, ok := strings.Cut(s, "=")
+	if !ok || literal == "" || strings.TrimSpace(expr) == "" {
+		return Replacement{}, fmt.Errorf("replacement %q must look like literal=expression, e.g. 8080=.Port", s)
+	}
+	return Replacement{Literal: literal, Expr: strings.TrimSpace(expr)}, nil
+}
+
+type Result struct {
+	Pack *matrix.Pack
+	// Files are the manifest and templates of the pack, relative to its
+	// directory.
+	Files []scaffold.File
+	// Captured counts the project files turned into templates.
+	Captured int
+	// Skipped lists files left out because they are not text, links
+	// pointing outside the project, or neither files nor links.
+	Skipped []string
+	// Unused lists literals that occur nowhere in the project.
+	Unused []string
+}
+
+// templateDir holds the templates inside the pack, mirroring the project.
+const templateDir = "files"
+
+// Capture reads the project in dir into a template pack. The pack is
+// checked by rendering it for every combination it supports before it is
+// returned.
+func Capture(dir string, opts Options) (*Result, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	if err := opts.fill(dir); err != nil {
+		return nil, err
+	}
+
+	replacements := opts.replacements()
+	// Braces already in the project are escaped in the same pass, so the
+	// actions put in are left alone. A lone }} is plain text to templates.
+	pairs := []string{`{{`, `{{"{{"}}`}
+	pathPairs := []string{}
+	for _, r := range replacements {
+		action := "{{" + r.Expr + "}}"
+		pairs = append(pairs, r.Literal, action)
+		pathPairs = append(pathPairs, r.Literal, action)
+	}
+	content := strings.NewReplacer(pairs...)
+	paths := strings.NewReplacer(pathPairs...)
+
+	res := &Result{}
+	pack := &matrix.Pack{
+		Name:         opts.Name,
+		Version:      opts.Version,
+		Description:  opts.Description,
+		Language:     opts.Language,
+		Framework:    opts.Framework,
+		ProjectTypes: opts.ProjectTypes,
+		Complexities: opts.Complexities,
+	}
+	var templates []scaffold.File
+	used := map[string]bool{}
+
+	files, skipped, err := walk(dir, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	res.Skipped = skipped
+	for _, f := range files {
+		full := filepath.Join(dir, filepath.FromSlash(f))
+		if info, err := os.Lstat(full); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(full)
+			if err != nil || !matrix.LinkInside(f, filepath.ToSlash(target)) {
+				res.Skipped = append(res.Skipped, f)
+				continue
+			}
+			pack.Files = append(pack.Files, matrix.PackFile{Path: paths.Replace(f), Link: filepath.ToSlash(target)})
+			continue
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			res.Skipped = append(res.Skipped, f)
+			continue
+		}
+
+		text := content.Replace(scaffold.StripHeader(f, string(data)))
+		out := paths.Replace(f)
+		for _, r := range replacements {
+			action := "{{" + r.Expr + "}}"
+			if strings.Contains(text, action) || strings.Contains(out, action) {
+				used[r.Literal] = true
+			}
+		}
+
+		tmpl := templateDir + "/" + f + ".tmpl"
+		templates = append(templates, scaffold.File{Path: tmpl, Data: []byte(text)})
+		pack.Files = append(pack.Files, matrix.PackFile{Path: out, Template: tmpl})
+	}
+	res.Captured = len(pack.Files)
+	if res.Captured == 0 {
+		return nil, fmt.Errorf("nothing to capture in %s", dir)
+	}
+	// The derived case forms of the app name often do not occur; only the
+	// literals as given are worth a warning.
+	given := []string{opts.ModulePath, opts.AppName}
+	for _, r := range opts.Replace {
+		given = append(given, r.Literal)
+	}
+	for _, r := range replacements {
+		if !used[r.Literal] && slices.Contains(given, r.Literal) {
+			res.Unused = append(res.Unused, r.Literal)
+		}
+	}
+
+	manifest, err := yaml.Marshal(pack)
+	if err != nil {
+		return nil, err
+	}
+	res.Files = append([]scaffold.File{{Path: "pack.yaml", Data: manifest}}, templates...)
+
+	fsys := fstest.MapFS{}
+	for _, f := range res.Files {
+		fsys[f.Path] = &fstest.MapFile{Data: f.Data}
+	}
+	if res.Pack, err = matrix.ReadPack(fsys, opts.Name); err == nil {
+		err = res.Pack.Check()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("captured pack does not render: %w", err)
+	}
+	return res, nil
+}
+
+// fill completes the options from what the project records about itself
+// and normalises the names against the registry.
+func (o *Options) fill(dir string) error {
+	reg := matrix.DefaultRegistry()
+
+	// A project generated by gen-code knows its own stack.
+	var recorded matrix.Spec
+	if m, err := scaffold.LoadManifest(dir); err == nil {
+		recorded = m.Spec
+	}
+
+	if o.Name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		o.Name = filepath.Base(abs)
+	}
+	if o.Version == "" {
+		o.Version = "0.1.0"
+	}
+	if o.Description == "" {
+		o.Description = "Captured from " + o.Name
+	}
+
+	if o.Language == "" {
+		o.Language = recorded.Language
+	}
+	if o.Language == "" {
+		o.Language = detectLanguage(dir)
+	}
+	if o.Language == "" {
+		return errors.New("cannot tell the language of the project; pass it with -language")
+	}
+	lang, err := reg.ParseLanguage(string(o.Language))
+	if err != nil {
+		return err
+	}
+	o.Language = lang
+
+	if strings.TrimSpace(string(o.Framework)) == "" {
+		return errors.New("the pack needs a framework name of its own; pass it with -framework")
+	}
+	if fw, err := reg.ParseFramework(lang, string(o.Framework)); err == nil {
+		return fmt.Errorf("%s is already a %s framework; a pack needs a new name", fw, lang)
+	}
+
+	if len(o.ProjectTypes) == 0 && recorded.ProjectType != "" {
+		o.ProjectTypes = []matrix.ProjectType{recorded.ProjectType}
+	}
+	for i, pt := range o.ProjectTypes {
+		if o.ProjectTypes[i], err = reg.ParseProjectType(string(pt)); err != nil {
+			return err
+		}
+	}
+	if len(o.Complexities) == 0 && recorded.Complexity != "" {
+		o.Complexities = []matrix.Complexity{recorded.Complexity}
+	}
+	for i, c := range o.Complexities {
+		if o.Complexities[i], err = reg.ParseComplexity(string(c)); err != nil {
+			return err
+		}
+	}
+
+	if o.ModulePath == "" {
+		o.ModulePath = recorded.ModulePath
+	}
+	if o.ModulePath == "" {
+		o.ModulePath = goModule(dir)
+	}
+	if o.AppName == "" {
+		o.AppName = recorded.AppName
+	}
+	return nil
+}
+
+// replacements lists every literal to replace, longest first so that a
+// module path wins over the app name inside it.
+func (o *Options) replacements() []Replacement {
+	var rs []Replacement
+	seen := map[string]bool{}
+	add := func(literal, expr string) {
+		if literal != "" && !seen[literal] {
+			seen[literal] = true
+			rs = append(rs, Replacement{literal, expr})
+		}
+	}
+	for _, r := range o.Replace {
+		add(r.Literal, r.Expr)
+	}
+	add(o.ModulePath, ".ModulePath")
+	if name := strings.TrimSpace(o.AppName); name != "" {
+		// The case forms come first: new app names are rarely already in
+		// kebab-case.
+		add(matrix.Kebab(name), "kebab .AppName")
+		add(matrix.Snake(name), "snake .AppName")
+		add(name, ".AppName")
+	}
+	slices.SortStableFunc(rs, func(a, b Replacement) int { return len(b.Literal) - len(a.Literal) })
+	return rs
+}
+
+func detectLanguage(dir string) matrix.Language {
+	for _, probe := range []struct {
+		file string
+		lang matrix.Language
+	}{
+		{"go.mod", matrix.Go},
+		{"package.json", matrix.JS},
+		{"requirements.txt", matrix.Python},
+		{"pyproject.toml", matrix.Python},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, probe.file)); err == nil {
+			return probe.lang
+		}
+	}
+	return ""
+}
+
+func goModule(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// walk lists the files to capture, slash separated and relative to dir,
+// and the ones it cannot capture because they are not regular files.
+// .gitignore files are honoured at every level, and gen-code's own
+// bookkeeping is left out, as are the dependencies its hooks install.
+func walk(dir string, exclude []string) (files, skipped []string, err error) {
+	var rules, excluded []ignoreRule
+	for _, p := range append([]string{".git/", ".gencode/", "/" + scaffold.ManifestFile, "/.venv/", "node_modules/"}, exclude...) {
+		if r, ok := parseRule("", p); ok {
+			excluded = append(excluded, r)
+		}
+	}
+
+	fsys := os.DirFS(dir)
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && (ignored(excluded, p, d.IsDir()) || ignored(rules, p, d.IsDir())) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case d.IsDir():
+			if data, err := fs.ReadFile(fsys, path.Join(p, ".gitignore")); err == nil {
+				base := p
+				if base == "." {
+					base = ""
+				}
+				rules = append(rules, parseIgnore(base, string(data))...)
+			}
+		case d.Type().IsRegular(), d.Type()&fs.ModeSymlink != 0:
+			files = append(files, p)
+		default:
+			skipped = append(skipped, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	return files, skipped, nil
+}
@@ -0,0 +1,134 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for p, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCapture(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"go.mod":                  "module github.com/acme/billing-api\n\ngo 1.22\n",
+		"main.go":                 "// Code generated by Gen Code; DO NOT EDIT.\n// Created by: Moeed ul Hassan\n// Project: Billing API\n\npackage main\n\nimport _ \"github.com/acme/billing-api/internal/db\"\n\nconst name = \"Billing API\"\n",
+		"cmd/billing-api/main.go": "package main\n",
+		"internal/db/db.go":       "package db\n\nconst file = \"billing_api.db\"\n",
+		"web/page.html":           "<h1>{{ .Title }}</h1>\n",
+		".gitignore":              "*.log\n/build/\n",
+		"debug.log":               "ignored\n",
+		"build/out.txt":           "ignored\n",
+		"internal/.gitignore":     "secret.txt\n",
+		"internal/secret.txt":     "ignored\n",
+		"docs/notes.md":           "excluded\n",
+		"logo.bin":                "\x00\x01",
+		".git/HEAD":               "ref: refs/heads/main\n",
+	})
+
+	for link, target := range map[string]string{"web/go.mod": "../go.mod", "outside": "../elsewhere"} {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(link))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := Capture(dir, Options{Framework: "GinBilling", AppName: "Billing API", Exclude: []string{"docs/"}, Replace: []Replacement{{"nowhere", ".Port"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pack.Language != matrix.Go || res.Pack.Name != filepath.Base(dir) {
+		t.Errorf("pack = %s %s", res.Pack.Language, res.Pack.Name)
+	}
+
+	var paths []string
+	for _, f := range res.Pack.Files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{".gitignore", "cmd/{{kebab .AppName}}/main.go", "go.mod", "internal/.gitignore", "internal/db/db.go", "main.go", "web/go.mod", "web/page.html"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+	if i := slices.IndexFunc(res.Pack.Files, func(f matrix.PackFile) bool { return f.Path == "web/go.mod" }); res.Pack.Files[i].Link != "../go.mod" {
+		t.Errorf("web/go.mod = %+v, want a link", res.Pack.Files[i])
+	}
+	if !slices.Equal(res.Skipped, []string{"logo.bin", "outside"}) || !slices.Equal(res.Unused, []string{"nowhere"}) {
+		t.Errorf("skipped = %q, unused = %q", res.Skipped, res.Unused)
+	}
+
+	templates := map[string]string{}
+	for _, f := range res.Files {
+		templates[f.Path] = string(f.Data)
+	}
+	for tmpl, want := range map[string]string{
+		"files/go.mod.tmpl":            "module {{.ModulePath}}\n",
+		"files/main.go.tmpl":           "package main\n\nimport _ \"{{.ModulePath}}/internal/db\"\n\nconst name = \"{{.AppName}}\"\n",
+		"files/internal/db/db.go.tmpl": "\"{{snake .AppName}}.db\"",
+		"files/web/page.html.tmpl":     `<h1>{{"{{"}} .Title }}</h1>`,
+	} {
+		if !strings.Contains(templates[tmpl], want) {
+			t.Errorf("%s = %q, want it to contain %q", tmpl, templates[tmpl], want)
+		}
+	}
+	if !strings.HasPrefix(templates["files/main.go.tmpl"], "package main") {
+		t.Errorf("header not stripped:\n%s", templates["files/main.go.tmpl"])
+	}
+}
+
+func TestCaptureRejects(t *testing.T) {
+	dir := writeTree(t, map[string]string{"go.mod": "module demo\n"})
+	for _, tc := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{}, "-framework"},
+		{Options{Framework: "gin"}, "already a Go framework"},
+		{Options{Framework: "Chi", Replace: []Replacement{{"demo", ".Nope"}}}, "does not render"},
+	} {
+		if _, err := Capture(dir, tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: got %v, want %q", tc.opts, err, tc.want)
+		}
+	}
+
+	if _, err := Capture(writeTree(t, map[string]string{"notes.txt": "x"}), Options{Framework: "Chi"}); err == nil || !strings.Contains(err.Error(), "-language") {
+		t.Errorf("undetectable language: got %v", err)
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	rules := parseIgnore("", "# comment\n*.log\n!keep.log\n/build/\nnode_modules/\ndocs/**/*.png\n")
+	rules = append(rules, parseIgnore("sub", "local.txt\n")...)
+	for p, want := range map[string]bool{
+		"app.log":              true,
+		"a/b/app.log":          true,
+		"keep.log":             false,
+		"build":                true,
+		"src/build":            false,
+		"x/node_modules":       true,
+		"docs/a/b/c.png":       true,
+		"docs/c.png":           true,
+		"img/c.png":            false,
+		"sub/local.txt":        true,
+		"local.txt":            false,
+		"sub/deeper/local.txt": true,
+	} {
+		if got := ignored(rules, p, !strings.Contains(p, ".")); got != want {
+			t.Errorf("ignored(%s) = %v, want %v", p, got, want)
+		}
+	}
+}
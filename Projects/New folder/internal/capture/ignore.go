@@ -0,0 +1,103 @@
+package capture
+
+import (
+	"path"
+	"strings"
+)
+
+// ignoreRule is one pattern from a .gitignore file, or from -exclude.
+type ignoreRule struct {
+	base     string // directory the rule applies below, "" for the root
+	segments []string
+	anchored bool // the pattern names a path, not just a base name
+	dirOnly  bool
+	negate   bool
+}
+
+// parseIgnore reads the rules of a .gitignore file in the directory base.
+// Blank lines and comments are skipped.
+func parseIgnore(base, text string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if r, ok := parseRule(base, line); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func parseRule(base, pattern string) (ignoreRule, bool) {
+	r := ignoreRule{base: base}
+	if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+		r.negate, pattern = true, rest
+	}
+	pattern = strings.TrimPrefix(pattern, `\`)
+	if rest, ok := strings.CutSuffix(pattern, "/"); ok {
+		r.dirOnly, pattern = true, rest
+	}
+	// As in git, a slash anywhere but the end ties the pattern to base.
+	r.anchored = strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return r, false
+	}
+	r.segments = strings.Split(pattern, "/")
+	return r, true
+}
+
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		var ok bool
+		if rel, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+			return false
+		}
+	}
+	name := strings.Split(rel, "/")
+	if !r.anchored {
+		// Directories are skipped as a whole, so matching the base name of
+		// every path on the way down is enough.
+		name = name[len(name)-1:]
+	}
+	return matchSegments(r.segments, name)
+}
+
+// matchSegments matches a path against a pattern one segment at a time;
+// "**" stands for any number of segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ignored applies the rules in order; the last one that matches decides.
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	skip := false
+	for _, r := range rules {
+		if r.match(rel, isDir) {
+			skip = !r.negate
+		}
+	}
+	return skip
+}
@@ -0,0 +1,122 @@
+package matrix
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// GoVersion is the go directive written to generated go.mod files and the
+// toolchain used by the generated Dockerfile.
+const GoVersion = "1.22"
+
+// Dependency is a third-party package a generated project needs.
+type Dependency struct {
+	// Name is the Go module path, npm package or PyPI distribution.
+	Name    string
+	Version string
+	// Import is the name used in source code when it differs from Name,
+	// e.g. the Flask distribution is imported as flask.
+	Import string
+	// Dev marks tools only needed to develop the project, written to
+	// devDependencies or requirements-dev.txt.
+	Dev bool
+}
+
+// catalog pins the packages every project of a framework needs. Versions
+// are bumped here and nowhere else.
+var catalog = map[Framework][]Dependency{
+	Gin:     {{Name: "github.com/gin-gonic/gin", Version: "v1.10.1"}},
+	Echo:    {{Name: "github.com/labstack/echo/v4", Version: "v4.12.0"}},
+	Fiber:   {{Name: "github.com/gofiber/fiber/v2", Version: "v2.52.5"}},
+	Express: {{Name: "express", Version: "4.21.1"}},
+	Fastify: {{Name: "fastify", Version: "4.28.1"}},
+	Flask:   {{Name: "Flask", Version: "3.0.3", Import: "flask"}},
+	FastAPI: {{Name: "fastapi", Version: "0.115.0"}, {Name: "uvicorn", Version: "0.30.6"}},
+	Django:  {{Name: "Django", Version: "5.1.1", Import: "django"}},
+}
+
+// Packages only some project types or complexities need.
+var (
+	ejs           = Dependency{Name: "ejs", Version: "3.1.10"}
+	fastifyStatic = Dependency{Name: "@fastify/static", Version: "7.0.4"}
+	fastifyView   = Dependency{Name: "@fastify/view", Version: "9.1.0"}
+	jinja2        = Dependency{Name: "Jinja2", Version: "3.1.4", Import: "jinja2"}
+	gunicorn      = Dependency{Name: "gunicorn", Version: "23.0.0"}
+	httpx         = Dependency{Name: "httpx", Version: "0.27.2", Dev: true}
+	pydantic      = Dependency{Name: "pydantic", Version: "2.9.2"}
+)
+
+// addonCatalog pins the packages each add-on needs per language. Add-ons
+// missing here, like SQLite for Python, only use the standard library.
+var addonCatalog = map[Language]map[Addon][]Dependency{
+	Go: {
+		SQLite:   {{Name: "modernc.org/sqlite", Version: "v1.33.1"}},
+		Postgres: {{Name: "github.com/jackc/pgx/v5", Version: "v5.7.1"}},
+		Mongo:    {{Name: "go.mongodb.org/mongo-driver", Version: "v1.17.1"}},
+	},
+	JS: {
+		SQLite:   {{Name: "better-sqlite3", Version: "11.3.0"}},
+		Postgres: {{Name: "pg", Version: "8.13.0"}},
+		Mongo:    {{Name: "mongodb", Version: "6.9.0"}},
+		Lint:     {{Name: "eslint", Version: "9.12.0", Dev: true}, {Name: "@eslint/js", Version: "9.12.0", Dev: true}},
+	},
+	Python: {
+		Postgres: {{Name: "psycopg[binary]", Version: "3.2.3", Import: "psycopg"}},
+		Mongo:    {{Name: "pymongo", Version: "4.10.1"}},
+		Lint:     {{Name: "ruff", Version: "0.6.9", Dev: true}},
+	},
+}
+
+// Dependencies lists the packages the project's manifest must declare,
+// sorted by name. Templates use it to write go.mod, package.json and
+// requirements.txt.
+func (s Spec) Dependencies() []Dependency {
+	return slices.DeleteFunc(s.allDependencies(), func(d Dependency) bool { return d.Dev })
+}
+
+// DevDependencies lists the development-only packages, sorted by name.
+func (s Spec) DevDependencies() []Dependency {
+	return slices.DeleteFunc(s.allDependencies(), func(d Dependency) bool { return !d.Dev })
+}
+
+func (s Spec) allDependencies() []Dependency {
+	deps := slices.Clone(catalog[s.Framework])
+	for _, a := range s.Addons {
+		deps = append(deps, addonCatalog[s.Language][a]...)
+	}
+
+	if s.IsWeb() {
+		switch s.Framework {
+		case Express:
+			deps = append(deps, ejs)
+		case Fastify:
+			deps = append(deps, fastifyStatic, fastifyView, ejs)
+		case FastAPI:
+			deps = append(deps, jinja2)
+		}
+	}
+	// The containers serve WSGI apps with gunicorn; uvicorn already covers FastAPI.
+	if (s.IsEnterprise() || s.Has(Docker)) && (s.Framework == Flask || s.Framework == Django) {
+		deps = append(deps, gunicorn)
+	}
+	// Contract models import pydantic, which FastAPI otherwise only pulls
+	// in indirectly.
+	if s.Contract != nil && s.Framework == FastAPI {
+		deps = append(deps, pydantic)
+	}
+	// FastAPI's TestClient is built on httpx.
+	if s.Has(Tests) && s.Framework == FastAPI {
+		deps = append(deps, httpx)
+	}
+
+	slices.SortFunc(deps, func(a, b Dependency) int {
+		return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return deps
+}
+
+// GoVersion is exposed to templates through the spec.
+func (s Spec) GoVersion() string {
+	return GoVersion
+}
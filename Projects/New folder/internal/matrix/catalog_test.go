@@ -0,0 +1,102 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuiltinImportsDeclared renders every built-in combination, bare and
+// with each database plus every other add-on, which runs CheckImports
+// against the catalog.
+func TestBuiltinImportsDeclared(t *testing.T) {
+	reg := NewRegistry()
+	for _, lang := range reg.Languages() {
+		for _, fw := range reg.Frameworks(lang.Name) {
+			for _, pt := range fw.ProjectTypes {
+				for _, c := range fw.Complexities {
+					for _, addons := range addonCombinations(reg.Addons(lang.Name, fw.Name, c)) {
+						spec := Spec{AppName: "demo", Language: lang.Name, Framework: fw.Name, ProjectType: pt, Complexity: c, Addons: addons}
+						if err := reg.Validate(spec); err != nil {
+							t.Errorf("%s/%s/%s %v: %v", fw.Name, pt, c, addons, err)
+							continue
+						}
+						if _, err := GetMatrix(spec); err != nil {
+							t.Errorf("%s/%s/%s %v: %v", fw.Name, pt, c, addons, err)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// addonCombinations returns no add-ons, then every ungrouped add-on
+// together with each database in turn.
+func addonCombinations(offered []AddonInfo) [][]Addon {
+	var rest, databases []Addon
+	for _, a := range offered {
+		if a.Group == "" {
+			rest = append(rest, a.Name)
+		} else {
+			databases = append(databases, a.Name)
+		}
+	}
+	combos := [][]Addon{nil}
+	for _, db := range databases {
+		combos = append(combos, append([]Addon{db}, rest...))
+	}
+	return combos
+}
+
+func TestValidateAddons(t *testing.T) {
+	reg := NewRegistry()
+	base := Spec{AppName: "demo", Language: Python, Framework: Django, ProjectType: Backend, Complexity: Enterprise}
+
+	tests := []struct {
+		addons []Addon
+		want   string
+	}{
+		{[]Addon{Postgres, Auth}, ""},
+		{[]Addon{SQLite, Postgres}, "pick one"},
+		{[]Addon{Mongo}, "not available"},
+		{[]Addon{Docker}, "not available"},
+	}
+	for _, tt := range tests {
+		spec := base
+		spec.Addons = tt.addons
+		err := reg.Validate(spec)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%v: got %v, want %q", tt.addons, err, tt.want)
+		}
+	}
+}
+
+func TestCheckImportsReportsUndeclared(t *testing.T) {
+	tests := []struct {
+		spec Spec
+		file FileTemplate
+		want string
+	}{
+		{
+			Spec{AppName: "demo", Language: Go, Framework: Gin},
+			FileTemplate{Path: "main.go", Content: "package main\n\nimport (\n\t\"fmt\"\n\n\t\"demo/internal/x\"\n\t\"github.com/gin-gonic/gin/render\"\n\t\"github.com/google/uuid\"\n)\n"},
+			`"github.com/google/uuid"`,
+		},
+		{
+			Spec{AppName: "demo", Language: JS, Framework: Express},
+			FileTemplate{Path: "src/app.js", Content: "const path = require('path')\nconst express = require('express')\nconst { z } = require('zod')\nconst x = require('./x')\n"},
+			`"zod"`,
+		},
+		{
+			Spec{AppName: "demo", Language: Python, Framework: Flask},
+			FileTemplate{Path: "app/x.py", Content: "import os, requests\nfrom flask import Flask\nfrom app.config import Config\nfrom . import y\n"},
+			`"requests"`,
+		},
+	}
+	for _, tt := range tests {
+		err := CheckImports(tt.spec, []FileTemplate{tt.file})
+		if err == nil || strings.Count(err.Error(), "\n") != 0 || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected exactly %s to be reported, got %v", tt.file.Path, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// contractFrameworks are the frameworks that can serve an OpenAPI
+// contract: route registration, request and response types, and handler
+// stubs that validate their input against the contract's schemas.
+var contractFrameworks = []Framework{Gin, Echo, Fiber, Express, Fastify, FastAPI}
+
+// reservedRoutes are paths the generated projects serve themselves.
+// Contract operations may not reuse them; Gin and Fiber would refuse to
+// start, and the other frameworks would silently serve only one of them.
+var reservedRoutes = []string{"/", "/health", "/ready", "/api/v1/ping", "/api/v1/hello", "/api/v1/private/whoami"}
+
+// validateContract checks that the project can serve spec.Contract.
+func validateContract(spec Spec, fw FrameworkInfo) error {
+	api := spec.Contract
+	if api == nil {
+		return nil
+	}
+	if fw.Pack != "" || !slices.Contains(contractFrameworks, fw.Name) {
+		return fmt.Errorf("%s projects cannot be generated from an OpenAPI contract (supported: %s)", fw.Name, join(contractFrameworks))
+	}
+
+	var errs []error
+	for i, op := range api.Operations {
+		route := op.Method + " " + op.Path
+		if slices.Contains(reservedRoutes, op.Path) || strings.HasPrefix(op.Path, "/static/") {
+			errs = append(errs, fmt.Errorf("contract operation %s uses a path gen-code generates itself", route))
+		}
+		// Gin keeps one tree per method in which a path segment is either
+		// one wildcard or not.
+		if spec.Framework == Gin {
+			for _, prev := range api.Operations[:i] {
+				if prev.Method == op.Method && wildcardsDiffer(prev.Path, op.Path) {
+					errs = append(errs, fmt.Errorf("%s and %s cannot both be served by Gin: path parameters in the same place must have the same name", prev.Method+" "+prev.Path, route))
+				}
+			}
+		}
+	}
+	if spec.Language == Go {
+		for _, p := range api.Patterns() {
+			if _, err := regexp.Compile(p.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("pattern %q is not supported by Go: %w", p.Pattern, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wildcardsDiffer reports whether two paths share a prefix that ends in
+// path parameters with different names.
+func wildcardsDiffer(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		aParam, bParam := strings.HasPrefix(as[i], "{"), strings.HasPrefix(bs[i], "{")
+		switch {
+		case aParam && bParam && as[i] != bs[i]:
+			return true
+		case aParam != bParam || as[i] != bs[i]:
+			return false
+		}
+	}
+	return false
+}
+
+// contractFiles returns the files that serve spec.Contract: its types and
+// validation, the handler stubs, and the code that registers them.
+func contractFiles(spec Spec) []FileTemplate {
+	if spec.Contract == nil {
+		return nil
+	}
+	switch spec.Language {
+	case Go:
+		return tree("go/contract")
+	case JS:
+		return tree("js/contract")
+	case Python:
+		return under(pythonPackage(spec), tree("python/contract"))
+	}
+	return nil
+}
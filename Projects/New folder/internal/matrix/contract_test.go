@@ -0,0 +1,128 @@
+package matrix
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gen-code/internal/openapi"
+)
+
+const contractDoc = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+        - {name: fields, in: query, schema: {type: string, pattern: "^[a-z,]+$"}}
+      responses:
+        "200":
+          content: {application/json: {schema: {$ref: "#/components/schemas/Pet"}}}
+  /pets:
+    post:
+      requestBody:
+        content: {application/json: {schema: {$ref: "#/components/schemas/NewPet"}}}
+      responses: {"201": {description: created}}
+components:
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, maxLength: 40}
+        tags: {type: array, items: {type: string}, maxItems: 5}
+    Pet:
+      allOf:
+        - $ref: "#/components/schemas/NewPet"
+        - type: object
+          required: [id]
+          properties:
+            id: {type: integer}
+            labels: {type: object, additionalProperties: {type: string}}
+            friends: {type: object, additionalProperties: {$ref: "#/components/schemas/NewPet"}}
+`
+
+// TestContractRenders renders the contract for every framework that
+// supports one, which also checks the imports of the generated files.
+func TestContractRenders(t *testing.T) {
+	api, err := openapi.Parse([]byte(contractDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry()
+	for _, lang := range reg.Languages() {
+		for _, fw := range reg.Frameworks(lang.Name) {
+			if !slices.Contains(contractFrameworks, fw.Name) {
+				continue
+			}
+			for _, c := range fw.Complexities {
+				spec := Spec{AppName: "demo", Language: lang.Name, Framework: fw.Name, ProjectType: fw.ProjectTypes[0], Complexity: c, Contract: api}
+				if err := reg.Validate(spec); err != nil {
+					t.Errorf("%s/%s: %v", fw.Name, c, err)
+					continue
+				}
+				m, err := GetMatrix(spec)
+				if err != nil {
+					t.Errorf("%s/%s: %v", fw.Name, c, err)
+					continue
+				}
+				if !rendersContract(m) {
+					t.Errorf("%s/%s: no file registers the contract", fw.Name, c)
+				}
+			}
+		}
+	}
+}
+
+func rendersContract(m ProjectMatrix) bool {
+	for _, f := range m.Files {
+		if strings.Contains(f.Content, "registerContract(") || strings.Contains(f.Content, "contract_router") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateContractRejects(t *testing.T) {
+	parse := func(doc string) *openapi.API {
+		api, err := openapi.Parse([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return api
+	}
+	pets := parse(contractDoc)
+	health := parse(`
+openapi: 3.0.0
+paths:
+  /health: {get: {responses: {}}}`)
+	wildcards := parse(`
+openapi: 3.0.0
+paths:
+  /a/{id}: {get: {responses: {}}}
+  /a/{name}/b: {get: {responses: {}}}`)
+
+	tests := []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Language: Python, Framework: Flask, ProjectType: Backend, Complexity: Minimal, Contract: pets}, "cannot be generated from an OpenAPI contract"},
+		{Spec{Language: JS, Framework: Express, ProjectType: Backend, Complexity: Minimal, Contract: health}, "generates itself"},
+		{Spec{Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Minimal, Contract: wildcards}, "cannot both be served by Gin"},
+	}
+	reg := NewRegistry()
+	for _, tt := range tests {
+		tt.spec.AppName = "demo"
+		err := reg.Validate(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.spec.Framework, err, tt.want)
+		}
+	}
+
+	echo := Spec{AppName: "demo", Language: Go, Framework: Echo, ProjectType: Backend, Complexity: Minimal, Contract: wildcards}
+	if err := reg.Validate(echo); err != nil {
+		t.Errorf("Echo: %v", err)
+	}
+}
@@ -1,5 +1,17 @@
 package matrix
 
+import (
+	"fmt"
+	"go/format"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gen-code/internal/openapi"
+)
+
 type ProjectType string
 type Complexity string
 type Language string
@@ -33,179 +45,336 @@ const (
 	Django  Framework = "Django"
 )
 
+// Spec is a fully answered wizard: everything needed to pick and render
+// the templates for one project. It is also the data passed to every template.
+type Spec struct {
+	AppName     string      `json:"app_name"`
+	Language    Language    `json:"language"`
+	Framework   Framework   `json:"framework"`
+	ProjectType ProjectType `json:"project_type"`
+	Complexity  Complexity  `json:"complexity"`
+	ModulePath  string      `json:"module_path,omitempty"`
+	Port        int         `json:"port,omitempty"`
+	Addons      []Addon     `json:"addons,omitempty"`
+
+	// Contract is the OpenAPI document the project serves, if any; see
+	// contract.go.
+	Contract *openapi.API `json:"contract,omitempty"`
+
+	// Entities are the domain types to generate CRUD layers for; see
+	// entities.go.
+	Entities []Entity `json:"entities,omitempty"`
+
+	// Vars holds the answers to the stack's prompts by name; see
+	// prompts.go.
+	Vars map[string]string `json:"vars,omitempty"`
+
+	// prompts overrides the registry's prompts while a pack that may not
+	// be registered yet is rendered.
+	prompts []Prompt
+}
+
+var defaultPorts = map[Framework]int{
+	Gin:     8080,
+	Echo:    1323,
+	Fiber:   3000,
+	Express: 3000,
+	Fastify: 3000,
+	Flask:   5000,
+	FastAPI: 8000,
+	Django:  8000,
+}
+
+func defaultPort(fw Framework) int {
+	if port, ok := defaultPorts[fw]; ok {
+		return port
+	}
+	return 8080
+}
+
+// WithDefaults fills in the values the user is allowed to leave empty.
+func (s Spec) WithDefaults() Spec {
+	if s.ModulePath == "" {
+		s.ModulePath = identifier(s.AppName, '-')
+	}
+	if s.Port == 0 {
+		s.Port = defaultPort(s.Framework)
+	}
+
+	// Recording every answer keeps upgrades stable, e.g. the copyright
+	// year does not move with the clock.
+	for _, p := range registry.Prompts(s) {
+		if _, ok := s.Vars[p.Name]; ok || p.Name == VarPort || p.Name == VarModulePath {
+			continue
+		}
+		s.Vars = maps.Clone(s.Vars)
+		if s.Vars == nil {
+			s.Vars = map[string]string{}
+		}
+		s.Vars[p.Name] = p.DefaultFor(s)
+	}
+	return s
+}
+
+// BinaryName is the file go build writes for the module: the last element
+// of its path, skipping a major version suffix.
+func (s Spec) BinaryName() string {
+	parts := strings.Split(s.ModulePath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	return name
+}
+
+func (s Spec) IsWeb() bool     { return s.ProjectType == WebApp }
+func (s Spec) IsCLI() bool     { return s.ProjectType == CLI }
+func (s Spec) IsBackend() bool { return s.ProjectType == Backend }
+
+// IsLayered reports whether the project gets service and repository layers.
+func (s Spec) IsLayered() bool    { return s.Complexity == Standard || s.Complexity == Enterprise }
+func (s Spec) IsEnterprise() bool { return s.Complexity == Enterprise }
+
 type FileTemplate struct {
 	Path    string
 	Content string
+
+	// Template names a file under templates/ that is rendered into Content.
+	Template string
+
+	// Asset names a file under templates/ that is copied into Content as
+	// is, for binary files such as images.
+	Asset string
+
+	// Mode holds the permission bits the file is written with; zero means
+	// 0644.
+	Mode fs.FileMode
+
+	// Dir marks Path as a directory the project needs even while it is
+	// empty. The scaffold engine keeps it with a .gitkeep file.
+	Dir bool
+
+	// NoHeader leaves the file exactly as rendered, without the generated-by
+	// header.
+	NoHeader bool
+
+	// Link makes Path a symbolic link to this slash-separated target,
+	// relative to the link's directory. It must stay inside the project.
+	Link string
+}
+
+// LinkInside reports whether a link at p pointing to target resolves to a
+// path inside the project.
+func LinkInside(p, target string) bool {
+	return target != "" && !path.IsAbs(target) && filepath.IsLocal(filepath.FromSlash(path.Join(path.Dir(p), target)))
 }
 
 type ProjectMatrix struct {
 	Files []FileTemplate
+
+	// Addons holds the files each selected add-on contributes, kept apart
+	// from Files so the scaffold engine can tell where a conflict comes from.
+	Addons []FileSet
+}
+
+// FileSet is the files one add-on adds to a project.
+type FileSet struct {
+	Addon Addon
+	Files []FileTemplate
 }
 
-func GetMatrix(lang Language, fw Framework, pt ProjectType, c Complexity) ProjectMatrix {
+func GetMatrix(spec Spec) (ProjectMatrix, error) {
+	spec = spec.WithDefaults()
+
+	if p := lookupPack(spec.Language, spec.Framework); p != nil {
+		files, err := p.render(spec)
+		if err != nil {
+			return ProjectMatrix{}, err
+		}
+		return ProjectMatrix{Files: files}, nil
+	}
+
 	var files []FileTemplate
 
-	switch lang {
+	switch spec.Language {
 	case Go:
-		files = getGoMatrix(fw, pt, c)
+		files = getGoMatrix(spec)
 	case JS:
-		files = getJSMatrix(fw, pt, c)
+		files = getJSMatrix(spec)
 	case Python:
-		files = getPythonMatrix(fw, pt, c)
+		files = getPythonMatrix(spec)
+	}
+	files = append(files, contractFiles(spec)...)
+	if err := render(files, spec); err != nil {
+		return ProjectMatrix{}, err
+	}
+	entities, err := entityFiles(spec, files)
+	if err != nil {
+		return ProjectMatrix{}, err
+	}
+	files = append(files, entities...)
+
+	sets := addonFiles(spec)
+	all := files
+	for _, set := range sets {
+		if err := render(set.Files, spec); err != nil {
+			return ProjectMatrix{}, err
+		}
+		all = append(all[:len(all):len(all)], set.Files...)
 	}
 
-	return ProjectMatrix{Files: files}
-}
-
-func getGoMatrix(fw Framework, pt ProjectType, c Complexity) []FileTemplate {
-	var files []FileTemplate
-	mainContent := ""
-
-	switch fw {
-	case Gin:
-		mainContent = `package main
-
-import "github.com/gin-gonic/gin"
+	// A package missing from the catalog would only show up when the user
+	// first builds the project, so catch it here.
+	if err := CheckImports(spec, all); err != nil {
+		return ProjectMatrix{}, fmt.Errorf("generated sources do not match their manifest: %w", err)
+	}
 
-func main() {
-	r := gin.Default()
-	r.GET("/ping", func(c *gin.Context) {
-		c.JSON(200, gin.H{"message": "pong"})
-	})
-	r.Run() // listen and serve on 0.0.0.0:8080
-}`
-	case Echo:
-		mainContent = `package main
+	return ProjectMatrix{Files: files, Addons: sets}, nil
+}
 
-import (
-	"net/http"
-	"github.com/labstack/echo/v4"
-)
+// render fills in the Content of every file that names a template or an
+// asset.
+func render(files []FileTemplate, data any) error {
+	for i, file := range files {
+		if file.Asset != "" {
+			raw, err := fs.ReadFile(templateFS, "templates/"+file.Asset)
+			if err != nil {
+				return fmt.Errorf("asset %s not found: %w", file.Asset, err)
+			}
+			files[i].Content, files[i].NoHeader = string(raw), true
+			continue
+		}
+		if file.Template == "" {
+			continue
+		}
+		content, err := renderTemplate(file.Template, data)
+		if err != nil {
+			return err
+		}
+
+		// Framework conditionals leave Go code unaligned; gofmt it so the
+		// output looks hand-written and template mistakes surface here.
+		if strings.HasSuffix(file.Path, ".go") {
+			formatted, err := format.Source([]byte(content))
+			if err != nil {
+				return fmt.Errorf("template %s produced invalid Go: %w", file.Template, err)
+			}
+			content = string(formatted)
+		}
+		files[i].Content = content
+	}
+	return nil
+}
 
-func main() {
-	e := echo.New()
-	e.GET("/", func(c echo.Context) error {
-		return c.String(http.StatusOK, "Hello, World!")
-	})
-	e.Logger.Fatal(e.Start(":1323"))
-}`
-	case Fiber:
-		mainContent = `package main
-
-import "github.com/gofiber/fiber/v2"
-
-func main() {
-    app := fiber.New()
-    app.Get("/", func(c *fiber.Context) error {
-        return c.SendString("Hello, Fiber!")
-    })
-    app.Listen(":3000")
-}`
+func getGoMatrix(spec Spec) []FileTemplate {
+	files := tree("go/common")
+	files = append(files, tree("go/config")...)
+
+	switch spec.ProjectType {
+	case CLI:
+		// The CLI's serve command reuses the backend server.
+		files = append(files, tree("go/backend")...)
+		files = append(files, tree("go/cli")...)
+	case WebApp:
+		files = append(files, tree("go/web")...)
+		files = append(files, tree("go/server-main")...)
+		files = append(files, staticAssets("web/static")...)
 	default:
-		mainContent = `package main
-
-import "fmt"
-
-func main() {
-	fmt.Println("Hello, Go!")
-}`
+		files = append(files, tree("go/backend")...)
+		files = append(files, tree("go/server-main")...)
 	}
 
-	files = append(files, FileTemplate{Path: "main.go", Content: mainContent})
-	files = append(files, FileTemplate{Path: "go.mod", Content: "module app\n\ngo 1.21"})
-
-	if c == Standard || c == Enterprise {
-		files = append(files, FileTemplate{Path: "internal/repository/repo.go", Content: "package repository"})
-		files = append(files, FileTemplate{Path: "internal/service/service.go", Content: "package service"})
+	if spec.IsLayered() {
+		files = append(files, tree("go/standard")...)
+	}
+	if spec.IsEnterprise() {
+		files = append(files, tree("go/enterprise")...)
+		files = append(files, tree("go/docker")...)
 	}
 
 	return files
 }
 
-func getJSMatrix(fw Framework, pt ProjectType, c Complexity) []FileTemplate {
-	var files []FileTemplate
-	mainContent := ""
-
-	switch fw {
-	case Express:
-		mainContent = `const express = require('express')
-const app = express()
-const port = 3000
-
-app.get('/', (req, res) => {
-  res.send('Hello Express!')
-})
-
-app.listen(port, () => {
-  console.log(` + "`Example app listening on port ${port}`" + `)
-})`
-	case Fastify:
-		mainContent = `const fastify = require('fastify')({ logger: true })
-
-fastify.get('/', async (request, reply) => {
-  return { hello: 'world' }
-})
-
-const start = async () => {
-  try {
-    await fastify.listen({ port: 3000 })
-  } catch (err) {
-    fastify.log.error(err)
-    process.exit(1)
-  }
-}
-start()`
+func getJSMatrix(spec Spec) []FileTemplate {
+	files := tree("js/common")
+	files = append(files, tree("js/config")...)
+
+	switch spec.ProjectType {
+	case CLI:
+		files = append(files, tree("js/backend")...)
+		files = append(files, tree("js/cli")...)
+	case WebApp:
+		files = append(files, tree("js/web")...)
+		files = append(files, tree("js/server-main")...)
+		files = append(files, staticAssets("public")...)
+	default:
+		files = append(files, tree("js/backend")...)
+		files = append(files, tree("js/server-main")...)
 	}
 
-	files = append(files, FileTemplate{Path: "index.js", Content: mainContent})
-	files = append(files, FileTemplate{Path: "package.json", Content: `{"name": "app", "version": "1.0.0", "scripts": {"start": "node index.js"}}`})
+	if spec.IsLayered() {
+		files = append(files, tree("js/standard")...)
+	}
+	if spec.IsEnterprise() {
+		files = append(files, tree("js/enterprise")...)
+		files = append(files, tree("js/docker")...)
+	}
 
 	return files
 }
 
-func getPythonMatrix(fw Framework, pt ProjectType, c Complexity) []FileTemplate {
-	var files []FileTemplate
-	mainContent := ""
-
-	switch fw {
-	case Flask:
-		mainContent = `from flask import Flask
-app = Flask(__name__)
-
-@app.route('/')
-def hello_world():
-    return 'Hello, Flask!'
-
-if __name__ == '__main__':
-    app.run()`
-	case FastAPI:
-		mainContent = `from fastapi import FastAPI
-
-app = FastAPI()
-
-@app.get("/")
-async def root():
-    return {"message": "Hello FastAPI"}
-`
-	case Django:
-		mainContent = `# Django project entry point
-import os
-import sys
-
-def main():
-    os.environ.setdefault('DJANGO_SETTINGS_MODULE', 'app.settings')
-    try:
-        from django.core.management import execute_from_command_line
-    except ImportError as exc:
-        raise ImportError("Couldn't import Django.") from exc
-    execute_from_command_line(sys.argv)
-
-if __name__ == '__main__':
-    main()`
-	}
-
-	files = append(files, FileTemplate{Path: "app.py", Content: mainContent})
-	files = append(files, FileTemplate{Path: "requirements.txt", Content: string(fw)})
+func getPythonMatrix(spec Spec) []FileTemplate {
+	files := tree("python/common")
+	if len(spec.DevDependencies()) > 0 {
+		files = append(files, FileTemplate{Path: "requirements-dev.txt", Template: "python/shared/requirements-dev.txt.tmpl"})
+	}
+
+	// Django keeps its code in the core app, the others in the app package.
+	pkg := pythonPackage(spec)
+	if spec.Framework == Django {
+		files = append(files, tree("python/django/common")...)
+		if spec.ProjectType == WebApp {
+			files = append(files, FileTemplate{Path: "templates/index.html", Template: "python/shared/index.html.tmpl"})
+			files = append(files, staticAssets("static")...)
+		}
+	} else {
+		files = append(files, tree("python/service")...)
+
+		switch spec.ProjectType {
+		case CLI:
+			files = append(files, tree("python/backend")...)
+			files = append(files, tree("python/cli")...)
+		case WebApp:
+			files = append(files, tree("python/web")...)
+			files = append(files, tree("python/server-main")...)
+			files = append(files, FileTemplate{Path: "app/templates/index.html", Template: "python/shared/index.html.tmpl"})
+			files = append(files, staticAssets("app/static")...)
+		default:
+			files = append(files, tree("python/backend")...)
+			files = append(files, tree("python/server-main")...)
+		}
+
+		if spec.IsLayered() {
+			files = append(files, tree("python/standard")...)
+		}
+	}
+
+	if spec.IsLayered() {
+		files = append(files, under(pkg, tree("python/layers"))...)
+	}
+	if spec.IsEnterprise() {
+		files = append(files, under(pkg, tree("python/enterprise-layers"))...)
+		files = append(files, tree("python/enterprise")...)
+		files = append(files, tree("python/docker")...)
+		if spec.Framework == Django {
+			files = append(files, tree("python/django/enterprise")...)
+			files = append(files, djangoEntrypoint)
+		}
+		// FastAPI runs under uvicorn, which handles shutdown itself.
+		if spec.Framework != FastAPI {
+			files = append(files, FileTemplate{Path: "gunicorn.conf.py", Template: "python/shared/gunicorn.conf.py.tmpl"})
+		}
+	}
 
 	return files
 }
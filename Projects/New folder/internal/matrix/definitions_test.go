@@ -0,0 +1,139 @@
+package matrix
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// renderFiles returns the files GetMatrix produces for spec, without add-ons,
+// by path.
+func renderFiles(t *testing.T, spec Spec) map[string]string {
+	t.Helper()
+	spec.AppName = "demo"
+	m, err := GetMatrix(spec)
+	if err != nil {
+		t.Fatalf("%s/%s/%s: %v", spec.Framework, spec.ProjectType, spec.Complexity, err)
+	}
+	files := map[string]string{}
+	for _, f := range m.Files {
+		files[f.Path] = f.Content
+	}
+	return files
+}
+
+// layouts lists, per language, the paths that only one project type gets.
+// Django has no CLI layout and keeps its own.
+var layouts = map[Language]map[ProjectType][]string{
+	Go: {
+		WebApp:  {"web/templates/index.html", "web/static/css/style.css", "web/embed.go"},
+		CLI:     {"internal/cli/cli.go", "internal/cli/version.go"},
+		Backend: {},
+	},
+	JS: {
+		WebApp:  {"views/index.ejs", "public/css/style.css", "index.js"},
+		CLI:     {"bin/cli.js", "src/cli.js"},
+		Backend: {"index.js"},
+	},
+	Python: {
+		WebApp:  {"app/templates/index.html", "app/static/css/style.css", "main.py"},
+		CLI:     {"app/__main__.py", "app/cli.py", "app/version.py"},
+		Backend: {"main.py"},
+	},
+	"Django": {
+		WebApp:  {"templates/index.html", "static/css/style.css"},
+		Backend: {},
+	},
+}
+
+// shared are the paths every project type of a language gets: its routes,
+// with the health check, and its configuration.
+var shared = map[Language][]string{
+	Go:       {"go.mod", "main.go", "internal/config/config.go", "internal/server/routes.go"},
+	JS:       {"package.json", "src/config.js", "src/routes.js"},
+	Python:   {"requirements.txt", "app/config.py", "app/routes.py"},
+	"Django": {"requirements.txt", "manage.py", "config/settings.py", "config/urls.py", "core/views.py"},
+}
+
+func TestProjectTypeLayouts(t *testing.T) {
+	reg := NewRegistry()
+	for _, lang := range reg.Languages() {
+		for _, fw := range reg.Frameworks(lang.Name) {
+			key := lang.Name
+			if fw.Name == Django {
+				key = "Django"
+			}
+			for _, pt := range fw.ProjectTypes {
+				files := renderFiles(t, Spec{Language: lang.Name, Framework: fw.Name, ProjectType: pt, Complexity: Minimal})
+				for _, p := range append(shared[key], layouts[key][pt]...) {
+					if _, ok := files[p]; !ok {
+						t.Errorf("%s/%s: no %s", fw.Name, pt, p)
+					}
+				}
+				// The paths that set the other project types apart are left out.
+				for other, paths := range layouts[key] {
+					for _, p := range paths {
+						if _, ok := files[p]; ok && other != pt && !slices.Contains(layouts[key][pt], p) {
+							t.Errorf("%s/%s: has the %s file %s", fw.Name, pt, other, p)
+						}
+					}
+				}
+
+				// Servers answer health checks; CLIs print their version.
+				want := "health"
+				if pt == CLI {
+					want = "version"
+				}
+				if !slices.ContainsFunc(layouts[key][pt], func(p string) bool { return strings.Contains(files[p], want) }) &&
+					!slices.ContainsFunc(shared[key], func(p string) bool { return strings.Contains(files[p], want) }) {
+					t.Errorf("%s/%s: no file mentions %s", fw.Name, pt, want)
+				}
+			}
+		}
+	}
+}
+
+// route reports whether any file registers the endpoint, in the styles of
+// the routers we generate for.
+func route(files map[string]string, endpoint string) bool {
+	for _, content := range files {
+		for _, form := range []string{`"/` + endpoint + `"`, `'/` + endpoint + `'`, `path("` + endpoint + `"`} {
+			if strings.Contains(content, form) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestComplexityTiers(t *testing.T) {
+	ops := []string{"Dockerfile", ".dockerignore", "docker-compose.yml", "Makefile"}
+	reg := NewRegistry()
+	for _, lang := range reg.Languages() {
+		for _, fw := range reg.Frameworks(lang.Name) {
+			for _, c := range fw.Complexities {
+				files := renderFiles(t, Spec{Language: lang.Name, Framework: fw.Name, ProjectType: Backend, Complexity: c})
+				enterprise := c == Enterprise
+
+				if !route(files, "health") {
+					t.Errorf("%s/%s: no /health", fw.Name, c)
+				}
+				if route(files, "ready") != enterprise {
+					t.Errorf("%s/%s: /ready registered = %v", fw.Name, c, !enterprise)
+				}
+				for _, p := range ops {
+					if _, ok := files[p]; ok != enterprise {
+						t.Errorf("%s/%s: %s written = %v", fw.Name, c, p, !enterprise)
+					}
+				}
+				tests := slices.ContainsFunc(slices.Collect(maps.Keys(files)), func(p string) bool {
+					return strings.Contains(p, "_test.") || strings.Contains(p, ".test.") || strings.Contains(p, "test_")
+				})
+				if tests != enterprise {
+					t.Errorf("%s/%s: example unit test written = %v", fw.Name, c, tests)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entity is a domain type described in an entities file. Each one gets the
+// layers gen-code add resource writes, with its own fields, plus a table in
+// the SQL schema.
+type Entity struct {
+	// Name is plural, like a resource's: "books" gives a Book type served
+	// under /api/v1/books and stored in the books table.
+	Name   string  `json:"name" yaml:"name"`
+	Fields []Field `json:"fields" yaml:"fields"`
+}
+
+// FieldType is the type of an entity field.
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldText   FieldType = "text"
+	FieldInt    FieldType = "int"
+	FieldFloat  FieldType = "float"
+	FieldBool   FieldType = "bool"
+	FieldTime   FieldType = "time"
+)
+
+var fieldTypes = []FieldType{FieldString, FieldText, FieldInt, FieldFloat, FieldBool, FieldTime}
+
+// Field is one column of an entity, next to the id every entity has.
+type Field struct {
+	Name     string    `json:"name" yaml:"name"`
+	Type     FieldType `json:"type" yaml:"type"`
+	Required bool      `json:"required,omitempty" yaml:"required,omitempty"`
+
+	// Max limits the length of a string field.
+	Max int `json:"max,omitempty" yaml:"max,omitempty"`
+
+	// References names the entity an int field holds the id of.
+	References string `json:"references,omitempty" yaml:"references,omitempty"`
+}
+
+// resourceFields are the fields of a resource added with gen-code add.
+var resourceFields = []Field{{Name: "name", Type: FieldString, Required: true}}
+
+var fieldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadEntities reads an entities file:
+//
+//	entities:
+//	  - name: books
+//	    fields:
+//	      - {name: title, type: string, required: true, max: 200}
+//	      - {name: price, type: float}
+//
+// JSON works too, as it is a subset of YAML.
+func LoadEntities(path string) ([]Entity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entities file: %w", err)
+	}
+	var file struct {
+		Entities []Entity `yaml:"entities"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse entities file %s: %w", path, err)
+	}
+	if len(file.Entities) == 0 {
+		return nil, fmt.Errorf("entities file %s declares no entities", path)
+	}
+	if err := checkEntities(file.Entities); err != nil {
+		return nil, fmt.Errorf("entities file %s: %w", path, err)
+	}
+	return file.Entities, nil
+}
+
+// checkEntities validates the entities on their own; validateEntities
+// checks them against the project.
+func checkEntities(entities []Entity) error {
+	var errs []error
+	seen := map[string]string{}
+	for i, e := range entities {
+		p := Piece{Kind: GenResource, Name: strings.TrimSpace(e.Name)}
+		if err := validatePieceName(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, key := range []string{p.Snake(), p.Type()} {
+			if prev, ok := seen[key]; ok {
+				errs = append(errs, fmt.Errorf("entities %q and %q would generate the same names", prev, e.Name))
+				break
+			}
+			seen[key] = e.Name
+		}
+		if len(e.Fields) == 0 {
+			errs = append(errs, fmt.Errorf("entity %s has no fields", e.Name))
+		}
+
+		names := map[string]bool{}
+		for _, f := range e.Fields {
+			if err := checkField(f, entities[:i+1]); err != nil {
+				errs = append(errs, fmt.Errorf("entity %s: %w", e.Name, err))
+			}
+			if names[f.Name] {
+				errs = append(errs, fmt.Errorf("entity %s: field %s is declared twice", e.Name, f.Name))
+			}
+			names[f.Name] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkField validates f. A field can only reference entities declared
+// before its own, or its own, so the migrations create tables in order.
+func checkField(f Field, declared []Entity) error {
+	switch {
+	case !fieldName.MatchString(f.Name):
+		return fmt.Errorf("field name %q must be snake_case", f.Name)
+	case f.Name == "id":
+		return errors.New("field id is added to every entity already")
+	case !slices.Contains(fieldTypes, f.Type):
+		return fmt.Errorf("field %s has unknown type %q (valid: %s)", f.Name, f.Type, join(fieldTypes))
+	case f.Max < 0 || f.Max > 0 && f.Type != FieldString:
+		return fmt.Errorf("field %s: max only applies to string fields, as a positive length", f.Name)
+	case f.References != "" && f.Type != FieldInt:
+		return fmt.Errorf("field %s: only int fields can reference another entity", f.Name)
+	case f.Required && !f.checkable():
+		return fmt.Errorf("field %s: required only applies to string, text and time fields and references", f.Name)
+	}
+	table := identifier(f.References, '_')
+	if f.References != "" && !slices.ContainsFunc(declared, func(e Entity) bool { return identifier(e.Name, '_') == table }) {
+		return fmt.Errorf("field %s references %q, which is not declared before it", f.Name, f.References)
+	}
+	return nil
+}
+
+// checkable reports whether a missing value can be told apart from a zero
+// one, which is what required checks.
+func (f Field) checkable() bool {
+	return f.Type == FieldString || f.Type == FieldText || f.Type == FieldTime || f.References != ""
+}
+
+// validateEntities checks that the project can hold spec.Entities.
+func validateEntities(spec Spec, fw FrameworkInfo) error {
+	if len(spec.Entities) == 0 {
+		return nil
+	}
+	if fw.Pack != "" || spec.Language != Go {
+		return fmt.Errorf("entities can only be generated for the built-in Go frameworks, not %s", fw.Name)
+	}
+	if spec.Database() == Mongo {
+		return errors.New("entities are stored in SQL tables; pick SQLite or Postgres instead of Mongo")
+	}
+	return checkEntities(spec.Entities)
+}
+
+// Resources returns the entities as the pieces the resource templates are
+// rendered with.
+func (s Spec) Resources() []Piece {
+	pieces := make([]Piece, len(s.Entities))
+	for i, e := range s.Entities {
+		pieces[i] = Piece{Spec: s, Kind: GenResource, Name: strings.TrimSpace(e.Name), Fields: e.Fields}
+	}
+	return pieces
+}
+
+// SQLDatabase is the SQL database add-on the project uses, or "" for none.
+// SQL files are written for Postgres unless SQLite was picked.
+func (s Spec) SQLDatabase() Addon {
+	if db := s.Database(); db != Mongo {
+		return db
+	}
+	return ""
+}
+
+// entityFiles renders the files of every entity: the layers of gen-code add
+// resource, and the migrations plus the schema they add up to. Entities may
+// not replace a file the project already has.
+func entityFiles(spec Spec, existing []FileTemplate) ([]FileTemplate, error) {
+	if len(spec.Entities) == 0 {
+		return nil, nil
+	}
+	taken := map[string]bool{}
+	for _, f := range existing {
+		taken[f.Path] = true
+	}
+
+	var files []FileTemplate
+	schema := "-- The schema all migrations in db/migrations add up to.\n"
+	for i, p := range spec.Resources() {
+		a := resourceAddition(p)
+		// The SQL repositories sit next to the memory ones, and SQLite can
+		// test them in memory.
+		if p.IsLayered() && spec.SQLDatabase() != "" {
+			a.Files = append(a.Files, tree("entities/sql")...)
+			if spec.SQLDatabase() == SQLite {
+				a.Files = append(a.Files, tree("entities/sqltest")...)
+			}
+		}
+		for _, f := range a.Files {
+			if taken[pieceFilePath(p, f.Path)] {
+				return nil, fmt.Errorf("entity %s would replace %s, which the project already has", p.Name, pieceFilePath(p, f.Path))
+			}
+		}
+		if err := renderPiece(p, a.Files); err != nil {
+			return nil, err
+		}
+
+		migration := fmt.Sprintf("db/migrations/%04d_create_%s", i+1, p.Snake())
+		sql := []FileTemplate{
+			{Path: migration + ".up.sql", Template: "entities/migration.up.sql.tmpl"},
+			{Path: migration + ".down.sql", Template: "entities/migration.down.sql.tmpl"},
+		}
+		if err := render(sql, p); err != nil {
+			return nil, err
+		}
+		schema += "\n" + sql[0].Content
+		files = append(files, a.Files...)
+		files = append(files, sql...)
+	}
+
+	files = append(files, FileTemplate{Path: "db/schema.sql", Content: schema})
+	if spec.SQLDatabase() != "" {
+		migrate := []FileTemplate{{Path: "db/migrations/migrations.go", Template: "entities/migrations.go.tmpl"}}
+		if err := render(migrate, spec); err != nil {
+			return nil, err
+		}
+		files = append(files, migrate...)
+	}
+	return files, nil
+}
+
+// GoName is the field's name in Go, e.g. "AuthorID" for author_id.
+func (f Field) GoName() string {
+	var b strings.Builder
+	for _, w := range strings.Split(f.Name, "_") {
+		switch {
+		case w == "":
+		case slices.Contains(goInitialisms, w):
+			b.WriteString(strings.ToUpper(w))
+		default:
+			b.WriteString(pascal([]string{w}))
+		}
+	}
+	return b.String()
+}
+
+var goInitialisms = []string{"api", "http", "id", "ip", "json", "sql", "uri", "url", "uuid"}
+
+// Optional reports whether the field may be left out, which only a time
+// field can be without a zero value standing in for it.
+func (f Field) Optional() bool { return f.Type == FieldTime && !f.Required }
+
+func (f Field) GoType() string {
+	switch f.Type {
+	case FieldInt:
+		return "int64"
+	case FieldFloat:
+		return "float64"
+	case FieldBool:
+		return "bool"
+	case FieldTime:
+		if f.Optional() {
+			return "*time.Time"
+		}
+		return "time.Time"
+	}
+	return "string"
+}
+
+func (f Field) GoTag() string {
+	if f.Optional() {
+		return fmt.Sprintf("`json:\"%s,omitempty\"`", f.Name)
+	}
+	return fmt.Sprintf("`json:\"%s\"`", f.Name)
+}
+
+// IsText reports whether the field holds a string, which is trimmed before
+// it is checked and stored.
+func (f Field) IsText() bool { return f.Type == FieldString || f.Type == FieldText }
+
+// FieldCheck is one rule a field's value must follow: Cond holds when the
+// value of item breaks it.
+type FieldCheck struct {
+	Cond    string
+	Message string
+}
+
+func (f Field) Checks() []FieldCheck {
+	v := "item." + f.GoName()
+	var checks []FieldCheck
+	if f.Required {
+		switch {
+		case f.IsText():
+			checks = append(checks, FieldCheck{v + ` == ""`, f.Name + " is required"})
+		case f.Type == FieldTime:
+			checks = append(checks, FieldCheck{v + ".IsZero()", f.Name + " is required"})
+		default:
+			checks = append(checks, FieldCheck{v + " <= 0", f.Name + " is required"})
+		}
+	}
+	if f.Max > 0 {
+		checks = append(checks, FieldCheck{fmt.Sprintf("utf8.RuneCountInString(%s) > %d", v, f.Max), fmt.Sprintf("%s must be at most %d characters", f.Name, f.Max)})
+	}
+	return checks
+}
+
+// SQLColumn is the column definition of the field for the given database.
+func (f Field) SQLColumn(db Addon) string {
+	var typ string
+	switch f.Type {
+	case FieldString:
+		typ = "TEXT"
+		if f.Max > 0 && db != SQLite {
+			typ = fmt.Sprintf("VARCHAR(%d)", f.Max)
+		}
+	case FieldText:
+		typ = "TEXT"
+	case FieldInt:
+		typ = "BIGINT"
+		if db == SQLite {
+			typ = "INTEGER"
+		}
+	case FieldFloat:
+		typ = "DOUBLE PRECISION"
+		if db == SQLite {
+			typ = "REAL"
+		}
+	case FieldBool:
+		typ = "BOOLEAN"
+	case FieldTime:
+		typ = "TIMESTAMPTZ"
+		if db == SQLite {
+			typ = "DATETIME"
+		}
+	}
+	col := fmt.Sprintf("%q %s", f.Name, typ)
+	if !f.Optional() {
+		col += " NOT NULL"
+	}
+	if f.References != "" {
+		col += fmt.Sprintf(" REFERENCES %q (\"id\")", identifier(f.References, '_'))
+	}
+	return col
+}
+
+// sample is the value the n-th generated test record holds in the field,
+// as JSON; n is 1 or 2.
+func (f Field) sample(n int) string {
+	switch f.Type {
+	case FieldInt:
+		return strconv.Itoa(n)
+	case FieldFloat:
+		return strconv.Itoa(n) + ".5"
+	case FieldBool:
+		return strconv.FormatBool(n == 1)
+	case FieldTime:
+		return fmt.Sprintf(`"%d-01-02T03:04:05Z"`, 2023+n)
+	}
+	s := []string{"first", "renamed"}[n-1]
+	if f.Max > 0 && len(s) > f.Max {
+		s = s[:f.Max]
+	}
+	return strconv.Quote(s)
+}
+
+// GoSample is sample as a Go expression.
+func (f Field) GoSample(n int) string {
+	if f.Type == FieldTime {
+		return fmt.Sprintf("time.Date(%d, 1, 2, 3, 4, 5, 0, time.UTC)", 2023+n)
+	}
+	return f.sample(n)
+}
+
+// JSON returns the "name":value pair of the n-th sample.
+func (f Field) JSON(n int) string {
+	return strconv.Quote(f.Name) + ":" + f.sample(n)
+}
+
+// Differ is the Go expression that holds when the field of a and b differ.
+func (f Field) Differ(a, b string) string {
+	if f.Type == FieldTime {
+		return fmt.Sprintf("!%s.%s.Equal(%s.%s)", a, f.GoName(), b, f.GoName())
+	}
+	return fmt.Sprintf("%s.%s != %s.%s", a, f.GoName(), b, f.GoName())
+}
+
+// Key is the first field every record has a value for, which the generated
+// tests look for in responses; nil if there is none.
+func (p Piece) Key() *Field {
+	for _, f := range p.Fields {
+		if !f.Optional() {
+			return &f
+		}
+	}
+	return nil
+}
+
+// HasText, HasMax, HasTime and HasChecks report what the fields need, for
+// the imports of the generated files.
+func (p Piece) HasText() bool { return slices.ContainsFunc(p.Fields, Field.IsText) }
+func (p Piece) HasMax() bool {
+	return slices.ContainsFunc(p.Fields, func(f Field) bool { return f.Max > 0 })
+}
+func (p Piece) HasTime() bool {
+	return slices.ContainsFunc(p.Fields, func(f Field) bool { return f.Type == FieldTime })
+}
+func (p Piece) HasChecks() bool {
+	return slices.ContainsFunc(p.Fields, func(f Field) bool { return len(f.Checks()) > 0 })
+}
+
+// HasRequiredTime reports whether GoLiteral uses the time package.
+func (p Piece) HasRequiredTime() bool {
+	return slices.ContainsFunc(p.Fields, func(f Field) bool { return f.Type == FieldTime && !f.Optional() })
+}
+
+// SampleJSON is the body of the n-th record the generated tests send.
+func (p Piece) SampleJSON(n int) string {
+	pairs := make([]string, len(p.Fields))
+	for i, f := range p.Fields {
+		pairs[i] = f.JSON(n)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// InvalidJSON is the first sample with its first checked field broken, or
+// "" when nothing is checked.
+func (p Piece) InvalidJSON() string {
+	i := slices.IndexFunc(p.Fields, func(f Field) bool { return len(f.Checks()) > 0 })
+	if i < 0 {
+		return ""
+	}
+	var pairs []string
+	for j, f := range p.Fields {
+		if j != i {
+			pairs = append(pairs, f.JSON(1))
+			continue
+		}
+		switch {
+		case f.Required && f.IsText():
+			pairs = append(pairs, strconv.Quote(f.Name)+`:" "`)
+		case f.Max > 0:
+			pairs = append(pairs, strconv.Quote(f.Name)+":"+strconv.Quote(strings.Repeat("x", f.Max+1)))
+		case f.References != "":
+			pairs = append(pairs, strconv.Quote(f.Name)+":0")
+		}
+		// A required time is left out.
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// GoLiteral lists the fields of the n-th sample record for a Go composite
+// literal, leaving out optional ones.
+func (p Piece) GoLiteral(n int) string {
+	var fields []string
+	for _, f := range p.Fields {
+		if !f.Optional() {
+			fields = append(fields, f.GoName()+": "+f.GoSample(n))
+		}
+	}
+	return strings.Join(fields, ", ")
+}
+
+// SQLParam is the n-th query parameter in the project's SQL dialect.
+func (p Piece) SQLParam(n int) string {
+	if p.SQLDatabase() == SQLite {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
+
+func (p Piece) SQLSelect() string {
+	cols := []string{`"id"`}
+	for _, f := range p.Fields {
+		cols = append(cols, strconv.Quote(f.Name))
+	}
+	return fmt.Sprintf("SELECT %s FROM %q", strings.Join(cols, ", "), p.Snake())
+}
+
+func (p Piece) SQLInsert() string {
+	cols := make([]string, len(p.Fields))
+	params := make([]string, len(p.Fields))
+	for i, f := range p.Fields {
+		cols[i], params[i] = strconv.Quote(f.Name), p.SQLParam(i+1)
+	}
+	return fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s) RETURNING "id"`, p.Snake(), strings.Join(cols, ", "), strings.Join(params, ", "))
+}
+
+func (p Piece) SQLUpdate() string {
+	sets := make([]string, len(p.Fields))
+	for i, f := range p.Fields {
+		sets[i] = fmt.Sprintf("%q = %s", f.Name, p.SQLParam(i+1))
+	}
+	return fmt.Sprintf(`UPDATE %q SET %s WHERE "id" = %s`, p.Snake(), strings.Join(sets, ", "), p.SQLParam(len(p.Fields)+1))
+}
+
+// ScanArgs and ValueArgs pass the fields of item to Scan and Exec, in the
+// column order of SQLSelect and SQLInsert.
+func (p Piece) ScanArgs() string {
+	args := []string{"&item.ID"}
+	for _, f := range p.Fields {
+		args = append(args, "&item."+f.GoName())
+	}
+	return strings.Join(args, ", ")
+}
+
+func (p Piece) ValueArgs() string {
+	args := make([]string, len(p.Fields))
+	for i, f := range p.Fields {
+		args[i] = "item." + f.GoName()
+	}
+	return strings.Join(args, ", ")
+}
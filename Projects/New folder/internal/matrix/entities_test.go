@@ -0,0 +1,83 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+)
+
+var testEntities = []Entity{
+	{Name: "authors", Fields: []Field{
+		{Name: "name", Type: FieldString, Required: true, Max: 100},
+		{Name: "born", Type: FieldTime},
+	}},
+	{Name: "books", Fields: []Field{
+		{Name: "title", Type: FieldText, Required: true},
+		{Name: "author_id", Type: FieldInt, References: "authors", Required: true},
+		{Name: "price", Type: FieldFloat},
+	}},
+}
+
+// TestEntitiesRender renders the entities for every Go framework and
+// complexity, with each SQL database and without one.
+func TestEntitiesRender(t *testing.T) {
+	reg := NewRegistry()
+	for _, fw := range reg.Frameworks(Go) {
+		for _, c := range fw.Complexities {
+			for _, addons := range [][]Addon{nil, {SQLite}, {Postgres}} {
+				spec := Spec{AppName: "demo", Language: Go, Framework: fw.Name, ProjectType: Backend, Complexity: c, Addons: addons, Entities: testEntities}
+				if err := reg.Validate(spec); err != nil {
+					t.Errorf("%s/%s %v: %v", fw.Name, c, addons, err)
+					continue
+				}
+				m, err := GetMatrix(spec)
+				if err != nil {
+					t.Errorf("%s/%s %v: %v", fw.Name, c, addons, err)
+					continue
+				}
+				paths := map[string]string{}
+				for _, f := range m.Files {
+					paths[f.Path] = f.Content
+				}
+				for _, want := range []string{"internal/server/books.go", "internal/server/books_test.go", "db/migrations/0002_create_books.up.sql", "db/schema.sql"} {
+					if _, ok := paths[want]; !ok {
+						t.Errorf("%s/%s %v: no %s", fw.Name, c, addons, want)
+					}
+				}
+				if !strings.Contains(paths["internal/server/routes.go"], "s.registerBooks(") {
+					t.Errorf("%s/%s %v: books are not registered", fw.Name, c, addons)
+				}
+				_, sqlRepo := paths["internal/repository/books_sql.go"]
+				if want := spec.IsLayered() && len(addons) > 0; sqlRepo != want {
+					t.Errorf("%s/%s %v: SQL repository generated = %v, want %v", fw.Name, c, addons, sqlRepo, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckEntitiesRejects(t *testing.T) {
+	tests := []struct {
+		entities []Entity
+		want     string
+	}{
+		{[]Entity{{Name: "books", Fields: []Field{{Name: "Title", Type: FieldString}}}}, "snake_case"},
+		{[]Entity{{Name: "books", Fields: []Field{{Name: "id", Type: FieldInt}}}}, "added to every entity"},
+		{[]Entity{{Name: "books", Fields: []Field{{Name: "pages", Type: "integer"}}}}, "unknown type"},
+		{[]Entity{{Name: "books", Fields: []Field{{Name: "pages", Type: FieldInt, Required: true}}}}, "required only applies"},
+		{[]Entity{{Name: "books", Fields: []Field{{Name: "author_id", Type: FieldInt, References: "authors"}}}}, "not declared before it"},
+		{[]Entity{{Name: "books"}}, "has no fields"},
+		{[]Entity{{Name: "books", Fields: resourceFields}, {Name: "book", Fields: resourceFields}}, "same names"},
+		{[]Entity{{Name: "health", Fields: resourceFields}}, "already has"},
+	}
+	for _, tt := range tests {
+		err := checkEntities(tt.entities)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%+v: got %v, want %q", tt.entities, err, tt.want)
+		}
+	}
+
+	js := Spec{AppName: "demo", Language: JS, Framework: Express, ProjectType: Backend, Complexity: Minimal, Entities: testEntities}
+	if err := NewRegistry().Validate(js); err == nil || !strings.Contains(err.Error(), "only be generated for the built-in Go") {
+		t.Errorf("Express: got %v", err)
+	}
+}
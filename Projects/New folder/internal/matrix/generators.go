@@ -0,0 +1,394 @@
+package matrix
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// GeneratorKind names something gen-code add can put into an existing
+// project.
+type GeneratorKind string
+
+const (
+	GenResource   GeneratorKind = "resource"
+	GenMiddleware GeneratorKind = "middleware"
+	GenCommand    GeneratorKind = "command"
+)
+
+var GeneratorKinds = []GeneratorKind{GenResource, GenMiddleware, GenCommand}
+
+func (k GeneratorKind) Description() string {
+	switch k {
+	case GenResource:
+		return "CRUD endpoints under /api/v1/<name>, layered like the rest of the project, with a test"
+	case GenMiddleware:
+		return "A middleware run around every request"
+	case GenCommand:
+		return "A subcommand of a CLI tool"
+	}
+	return ""
+}
+
+func ParseGeneratorKind(s string) (GeneratorKind, error) {
+	for _, k := range GeneratorKinds {
+		if matches(s, string(k)) {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown generator %q (valid: %s)", s, join(GeneratorKinds))
+}
+
+// Piece is the data generator templates are rendered with: the project's
+// Spec plus the name of what is added, in the forms each language needs.
+type Piece struct {
+	Spec
+	Kind GeneratorKind
+	// Name is the name as typed, e.g. "user profiles". Resources are named
+	// in the plural; the singular forms drop the last word's plural ending.
+	Name string
+	// Fields are a resource's fields besides its id.
+	Fields []Field
+}
+
+func (p Piece) Snake() string  { return identifier(p.Name, '_') }
+func (p Piece) Kebab() string  { return identifier(p.Name, '-') }
+func (p Piece) Camel() string  { return camel(words(p.Name)) }
+func (p Piece) Pascal() string { return pascal(words(p.Name)) }
+func (p Piece) Words() string  { return identifier(p.Name, ' ') }
+
+// Item, ItemSnake, ItemWords and Type name one element of a resource, e.g.
+// "userProfile", "user_profile", "user profile" and "UserProfile".
+func (p Piece) Item() string      { return camel(p.singular()) }
+func (p Piece) ItemSnake() string { return strings.Join(p.singular(), "_") }
+func (p Piece) ItemWords() string { return strings.Join(p.singular(), " ") }
+func (p Piece) Type() string      { return pascal(p.singular()) }
+
+func (p Piece) singular() []string {
+	w := words(p.Name)
+	last := w[len(w)-1]
+	switch {
+	case strings.HasSuffix(last, "ies") && len(last) > 3:
+		last = strings.TrimSuffix(last, "ies") + "y"
+	case strings.HasSuffix(last, "sses"), strings.HasSuffix(last, "xes"), strings.HasSuffix(last, "ches"), strings.HasSuffix(last, "shes"):
+		last = strings.TrimSuffix(last, "es")
+	case strings.HasSuffix(last, "s") && !strings.HasSuffix(last, "ss") && len(last) > 1:
+		last = strings.TrimSuffix(last, "s")
+	}
+	return append(w[:len(w)-1:len(w)-1], last)
+}
+
+func words(s string) []string {
+	return strings.Split(identifier(s, ' '), " ")
+}
+
+func camel(w []string) string {
+	return w[0] + pascal(w[1:])
+}
+
+func pascal(w []string) string {
+	var b strings.Builder
+	for _, word := range w {
+		r := []rune(word)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	return b.String()
+}
+
+// Insertion adds Text to a file the project already has. Where it goes:
+//
+//   - Replace: in place of the first line matching it
+//   - Sorted: among the lines matching it, in order
+//   - After and Before: after the first line matching After, and before the
+//     first line matching Before that follows it, ahead of any blank lines
+//     leading up to that line
+//   - none of them: at the end of the file
+//
+// An insertion whose Text is already in the file is skipped, so edits that
+// several pieces share, like an extra import, are only made once.
+type Insertion struct {
+	Path    string
+	After   string
+	Before  string
+	Sorted  string
+	Replace string
+	Text    string
+
+	// Template names a file under templates/ rendered into Text.
+	Template string
+
+	// Unique matches what the file holds once the piece has been added; the
+	// generator refuses to add it twice.
+	Unique string
+}
+
+// Addition is what a generator contributes: new files, and edits to files
+// the project already has.
+type Addition struct {
+	Files      []FileTemplate
+	Insertions []Insertion
+}
+
+// reservedPieces are taken by what every generated project already has.
+var reservedPieces = map[GeneratorKind][]string{
+	GenResource: {"greeting", "health", "hello", "memory", "ping", "private", "ready", "server", "whoami"},
+	GenCommand:  {"help", "hello", "serve", "version"},
+}
+
+var pythonKeywords = []string{
+	"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del", "elif", "else",
+	"except", "finally", "for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal", "not",
+	"or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+// GetAddition renders what a generator of the given kind adds to a project
+// generated from spec. The files land where GetMatrix put their neighbours
+// for the same spec.
+func GetAddition(spec Spec, kind GeneratorKind, name string) (Addition, error) {
+	spec = spec.WithDefaults()
+	if lookupPack(spec.Language, spec.Framework) != nil {
+		return Addition{}, fmt.Errorf("gen-code add only knows the built-in layouts, and %s/%s comes from a template pack", spec.Language, spec.Framework)
+	}
+	if kind == GenCommand && !spec.IsCLI() {
+		return Addition{}, fmt.Errorf("commands can only be added to CLI tools, not to a %s", spec.ProjectType)
+	}
+
+	p := Piece{Spec: spec, Kind: kind, Name: strings.TrimSpace(name), Fields: resourceFields}
+	if err := validatePieceName(p); err != nil {
+		return Addition{}, err
+	}
+
+	var a Addition
+	switch kind {
+	case GenResource:
+		a = resourceAddition(p)
+	case GenMiddleware:
+		a = middlewareAddition(p)
+	case GenCommand:
+		a = commandAddition(p)
+	default:
+		return Addition{}, fmt.Errorf("unknown generator %q", kind)
+	}
+
+	if err := renderPiece(p, a.Files); err != nil {
+		return Addition{}, err
+	}
+	for i, ins := range a.Insertions {
+		if ins.Template == "" {
+			continue
+		}
+		text, err := renderTemplate(ins.Template, p)
+		if err != nil {
+			return Addition{}, err
+		}
+		a.Insertions[i].Text = text
+	}
+	return a, nil
+}
+
+// renderPiece places and renders the files of a generator for p.
+func renderPiece(p Piece, files []FileTemplate) error {
+	for i := range files {
+		files[i].Path = pieceFilePath(p, files[i].Path)
+	}
+	return render(files, p)
+}
+
+// pieceFilePath is where a generator template's output goes: templates are
+// named after the piece they add.
+func pieceFilePath(p Piece, path string) string {
+	names := strings.NewReplacer("__snake__", p.Snake(), "__camel__", p.Camel(), "__item__", p.Item())
+	path = names.Replace(path)
+	if rest, ok := strings.CutPrefix(path, "_pkg/"); ok {
+		path = pythonPackage(p.Spec) + "/" + rest
+	}
+	return path
+}
+
+func validatePieceName(p Piece) error {
+	if p.Name == "" {
+		return fmt.Errorf("%s name is required", p.Kind)
+	}
+	if !unicode.IsLetter([]rune(p.Name)[0]) {
+		return fmt.Errorf("%s name %q must start with a letter", p.Kind, p.Name)
+	}
+	for _, r := range p.Name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_", r) {
+			return fmt.Errorf("%s name %q may only contain letters, digits, spaces, '-' and '_'", p.Kind, p.Name)
+		}
+	}
+	reserved := reservedPieces[p.Kind]
+	if slices.Contains(reserved, p.Snake()) || p.Kind == GenResource && slices.Contains(reserved, p.ItemSnake()) {
+		return fmt.Errorf("%s name %q is taken by what every generated project already has", p.Kind, p.Name)
+	}
+	if p.Language == Python && (slices.Contains(pythonKeywords, p.Snake()) || slices.Contains(pythonKeywords, p.ItemSnake())) {
+		return fmt.Errorf("%s name %q is a Python keyword", p.Kind, p.Name)
+	}
+	return nil
+}
+
+// withTests reports whether the project can run the test a generator adds.
+// Go always can; JavaScript and Python projects have a test setup with the
+// Tests add-on or at Enterprise complexity, though FastAPI's test client
+// needs the httpx the add-on brings.
+func withTests(spec Spec) bool {
+	switch {
+	case spec.Language == Go, spec.Has(Tests):
+		return true
+	case spec.Framework == FastAPI:
+		return false
+	}
+	return spec.IsEnterprise()
+}
+
+func generatorDir(p Piece) string {
+	lang := map[Language]string{Go: "go", JS: "js", Python: "python"}[p.Language]
+	return "generators/" + lang + "/" + string(p.Kind)
+}
+
+// pyRelativeImport is the anchor for the relative imports at the top of a Python
+// module.
+const pyRelativeImport = `^from \.\w+ import `
+
+func resourceAddition(p Piece) Addition {
+	dir := generatorDir(p)
+	a := Addition{Files: tree(dir + "/handler")}
+	if p.IsLayered() && p.Language != Python {
+		a.Files = append(a.Files, tree(dir+"/layers")...)
+	}
+	if withTests(p.Spec) {
+		a.Files = append(a.Files, tree(dir+"/test")...)
+	}
+
+	q := regexp.QuoteMeta
+	switch p.Language {
+	case Go:
+		a.Insertions = []Insertion{{
+			Path:   "internal/server/routes.go",
+			After:  `^func \(s \*Server\) routes\(\) \{$`,
+			Before: `^\}$`,
+			Text:   fmt.Sprintf("\ts.register%s(s.router.Group(\"/api/v1\"))", p.Pascal()),
+			Unique: `\bregister` + q(p.Pascal()) + `\(`,
+		}}
+	case JS:
+		a.Insertions = []Insertion{
+			{Path: "src/app.js", After: `^const \{ registerRoutes \} = require\('\./routes'\)$`, Before: `^$`, Text: fmt.Sprintf("const { register%s } = require('./%s')", p.Pascal(), p.Camel())},
+			{Path: "src/app.js", Before: `^  return app$`, Text: fmt.Sprintf("  register%s(app)", p.Pascal()), Unique: `\bregister` + q(p.Pascal()) + `\(app\)`},
+		}
+	case Python:
+		if p.Framework == Django {
+			a.Insertions = []Insertion{
+				{Path: "config/urls.py", Replace: `^from django\.urls import path$`, Text: "from django.urls import include, path"},
+				{Path: "config/urls.py", After: `^urlpatterns = \[$`, Before: `^\]$`, Text: fmt.Sprintf("    path(\"api/v1/\", include(\"core.%s\")),", p.Snake()), Unique: q(`include("core.` + p.Snake() + `")`)},
+			}
+		} else {
+			attr, register := "bp", "app.register_blueprint"
+			if p.Framework == FastAPI {
+				attr, register = "router", "app.include_router"
+			}
+			alias := p.Snake() + "_" + attr
+			a.Insertions = []Insertion{
+				{Path: "app/__init__.py", Sorted: pyRelativeImport, Text: fmt.Sprintf("from .%s import %s as %s", p.Snake(), attr, alias)},
+				{Path: "app/__init__.py", Before: `^    return app$`, Text: fmt.Sprintf("    %s(%s)", register, alias), Unique: `\(` + q(alias) + `\)`},
+			}
+		}
+		if p.IsLayered() {
+			pkg := pythonPackage(p.Spec)
+			a.Insertions = append(a.Insertions,
+				Insertion{Path: pkg + "/repositories.py", Template: dir + "/repositories.py.tmpl", Unique: `^class Memory` + q(p.Type()) + `Repository\b`},
+				Insertion{Path: pkg + "/services.py", Template: dir + "/services.py.tmpl", Unique: `^class ` + q(p.Type()) + `Service\b`},
+			)
+		}
+	}
+	return a
+}
+
+func middlewareAddition(p Piece) Addition {
+	a := Addition{Files: tree(generatorDir(p) + "/files")}
+	q := regexp.QuoteMeta
+
+	switch p.Language {
+	case Go:
+		use := "s.%sMiddleware"
+		if p.Framework == Gin {
+			use += "()"
+		}
+		// Gin and Fiber only run middleware on routes registered after it.
+		a.Insertions = []Insertion{{
+			Path:   "internal/server/routes.go",
+			After:  `^func \(s \*Server\) routes\(\) \{$`,
+			Before: `^\ts\.router\.(GET|Get)\(`,
+			Text:   fmt.Sprintf("\ts.router.Use("+use+")", p.Camel()),
+			Unique: `\b` + q(p.Camel()) + `Middleware\b`,
+		}}
+	case JS:
+		use := "  app.use(%sMiddleware)"
+		if p.Framework == Fastify {
+			use = "  app.addHook('onRequest', %sMiddleware)"
+		}
+		a.Insertions = []Insertion{
+			{Path: "src/app.js", After: `^const \{ registerRoutes \} = require\('\./routes'\)$`, Before: `^$`, Text: fmt.Sprintf("const { %sMiddleware } = require('./%s')", p.Camel(), p.Camel())},
+			{Path: "src/app.js", Before: `^  registerRoutes\(app, config\)$`, Text: fmt.Sprintf(use, p.Camel()), Unique: `\b` + q(p.Camel()) + `Middleware\)`},
+		}
+	case Python:
+		switch p.Framework {
+		case Django:
+			a.Insertions = []Insertion{{
+				Path:   "config/settings.py",
+				After:  `^MIDDLEWARE = \[$`,
+				Before: `^\]$`,
+				Text:   fmt.Sprintf("    \"core.%s.%sMiddleware\",", p.Snake(), p.Pascal()),
+				Unique: q(`"core.` + p.Snake() + `.`),
+			}}
+		case Flask:
+			a.Insertions = []Insertion{
+				{Path: "app/__init__.py", Sorted: pyRelativeImport, Text: fmt.Sprintf("from .%s import init_app as init_%s", p.Snake(), p.Snake())},
+				{Path: "app/__init__.py", Before: `^    return app$`, Text: fmt.Sprintf("    init_%s(app)", p.Snake()), Unique: `\binit_` + q(p.Snake()) + `\(app\)`},
+			}
+		case FastAPI:
+			a.Insertions = []Insertion{
+				{Path: "app/__init__.py", Sorted: pyRelativeImport, Text: fmt.Sprintf("from .%s import %s_middleware", p.Snake(), p.Snake())},
+				{Path: "app/__init__.py", Before: `^    return app$`, Text: fmt.Sprintf("    app.middleware(\"http\")(%s_middleware)", p.Snake()), Unique: `\(` + q(p.Snake()) + `_middleware\)`},
+			}
+		}
+	}
+	return a
+}
+
+func commandAddition(p Piece) Addition {
+	a := Addition{Files: tree(generatorDir(p) + "/files")}
+	q := regexp.QuoteMeta
+
+	switch p.Language {
+	case Go:
+		a.Insertions = []Insertion{{
+			Path:   "internal/cli/cli.go",
+			After:  `^\treturn \[\]command\{$`,
+			Before: `^\t\}$`,
+			Text:   fmt.Sprintf("\t\t{%q, \"Describe %s here\", %sCmd},", p.Kebab(), p.Kebab(), p.Camel()),
+			Unique: q(fmt.Sprintf("{%q,", p.Kebab())),
+		}}
+	case JS:
+		a.Insertions = []Insertion{{
+			Path:   "src/cli.js",
+			After:  `^const commands = \{$`,
+			Before: `^\}$`,
+			Text:   fmt.Sprintf("  '%s': require('./commands/%s'),", p.Kebab(), p.Camel()),
+			Unique: `^  '?` + q(p.Kebab()) + `'?: `,
+		}}
+	case Python:
+		a.Insertions = []Insertion{
+			{Path: "app/cli.py", Sorted: pyRelativeImport, Text: fmt.Sprintf("from .%s import cmd_%s", p.Snake(), p.Snake())},
+			{
+				Path:   "app/cli.py",
+				After:  `^def build_parser\(\):$`,
+				Before: `^    return parser$`,
+				Text:   fmt.Sprintf("\n    %s = sub.add_parser(%q, help=\"describe %s here\")\n    %s.set_defaults(func=cmd_%s)", p.Snake(), p.Kebab(), p.Kebab(), p.Snake(), p.Snake()),
+				Unique: q(fmt.Sprintf("sub.add_parser(%q", p.Kebab())),
+			},
+		}
+	}
+	return a
+}
@@ -0,0 +1,48 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPieceNames(t *testing.T) {
+	tests := []struct {
+		name                    string
+		snake, camel, item, typ string
+	}{
+		{"orders", "orders", "orders", "order", "Order"},
+		{"user profiles", "user_profiles", "userProfiles", "userProfile", "UserProfile"},
+		{"Categories", "categories", "categories", "category", "Category"},
+		{"address-book", "address_book", "addressBook", "addressBook", "AddressBook"},
+		{"access", "access", "access", "access", "Access"},
+		{"boxes", "boxes", "boxes", "box", "Box"},
+	}
+	for _, tt := range tests {
+		p := Piece{Name: tt.name}
+		if p.Snake() != tt.snake || p.Camel() != tt.camel || p.Item() != tt.item || p.Type() != tt.typ {
+			t.Errorf("%q: got %s %s %s %s", tt.name, p.Snake(), p.Camel(), p.Item(), p.Type())
+		}
+	}
+}
+
+func TestGetAdditionRejects(t *testing.T) {
+	py := Spec{AppName: "shop", Language: Python, Framework: Flask, ProjectType: Backend, Complexity: Minimal}
+	tests := []struct {
+		spec Spec
+		kind GeneratorKind
+		name string
+		want string
+	}{
+		{py, GenCommand, "sync", "only be added to CLI tools"},
+		{py, GenResource, "greetings", "already has"},
+		{py, GenResource, "classes", "Python keyword"},
+		{py, GenMiddleware, "9lives", "start with a letter"},
+		{py, GenMiddleware, "rate/limit", "may only contain"},
+	}
+	for _, tt := range tests {
+		_, err := GetAddition(tt.spec, tt.kind, tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: got %v, want %q", tt.kind, tt.name, err, tt.want)
+		}
+	}
+}
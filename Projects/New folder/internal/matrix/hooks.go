@@ -0,0 +1,100 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Hook is a command run in the project directory once it is generated,
+// such as go mod tidy. Hooks need the tools they call and often the
+// network, so they can be skipped.
+type Hook struct {
+	Name string `json:"name" yaml:"name"`
+	// Run is the program and its arguments. It is not passed to a shell.
+	Run []string `json:"run" yaml:"run"`
+	// Timeout is a duration such as 5m; empty means DefaultHookTimeout.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Unless is a command run first in the same way; when it succeeds the
+	// hook is skipped.
+	Unless []string `json:"unless,omitempty" yaml:"unless,omitempty"`
+
+	// ProjectTypes and Complexities restrict where the hook runs; empty
+	// means everywhere.
+	ProjectTypes []ProjectType `json:"project_types,omitempty" yaml:"project_types,omitempty"`
+	Complexities []Complexity  `json:"complexities,omitempty" yaml:"complexities,omitempty"`
+
+	when func(Spec) bool
+}
+
+const DefaultHookTimeout = time.Minute
+
+// Installing dependencies downloads them, which takes longer than the rest.
+// A project generated inside an existing repository belongs to it rather
+// than getting a nested one.
+var hooks = []Hook{
+	{Name: "git init", Run: []string{"git", "init", "--quiet"},
+		Unless: []string{"git", "rev-parse", "--is-inside-work-tree"}},
+	{Name: "go mod tidy", Run: []string{"go", "mod", "tidy"}, Timeout: "5m",
+		when: func(s Spec) bool { return s.Language == Go }},
+	{Name: "gofmt", Run: []string{"gofmt", "-l", "-w", "."},
+		when: func(s Spec) bool { return s.Language == Go }},
+	{Name: "npm install", Run: []string{"npm", "install", "--no-audit", "--no-fund"}, Timeout: "5m",
+		when: func(s Spec) bool { return s.Language == JS }},
+	{Name: "python venv", Run: []string{"python3", "-m", "venv", ".venv"},
+		when: func(s Spec) bool { return s.Language == Python }},
+}
+
+// Hooks returns the hooks to run after generating spec, in order: the
+// built-in ones for built-in frameworks, or the ones a pack declares.
+func (r *Registry) Hooks(spec Spec) []Hook {
+	info, ok := r.Framework(spec.Language, string(spec.Framework))
+	if !ok {
+		return nil
+	}
+	candidates := hooks
+	if info.Pack != "" {
+		candidates = info.Hooks
+	}
+
+	var list []Hook
+	for _, h := range candidates {
+		if len(h.ProjectTypes) > 0 && !slices.Contains(h.ProjectTypes, spec.ProjectType) {
+			continue
+		}
+		if len(h.Complexities) > 0 && !slices.Contains(h.Complexities, spec.Complexity) {
+			continue
+		}
+		if h.when != nil && !h.when(spec) {
+			continue
+		}
+		list = append(list, h)
+	}
+	return list
+}
+
+// Limit is how long the hook may run.
+func (h Hook) Limit() time.Duration {
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return DefaultHookTimeout
+	}
+	return d
+}
+
+// check validates a hook declared by a pack.
+func (h Hook) check() error {
+	if len(h.Run) == 0 || h.Run[0] == "" {
+		return errors.New("every hook needs a command to run")
+	}
+	if len(h.Unless) > 0 && h.Unless[0] == "" {
+		return fmt.Errorf("hook %s has an empty unless command", h.Name)
+	}
+	if h.Timeout != "" {
+		if d, err := time.ParseDuration(h.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("hook %s has a bad timeout %q (e.g. 30s or 5m)", h.Name, h.Timeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHooksFollowTheStack(t *testing.T) {
+	reg := NewRegistry()
+	tests := []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Minimal}, "git init,go mod tidy,gofmt"},
+		{Spec{Language: JS, Framework: Express, ProjectType: CLI, Complexity: Standard}, "git init,npm install"},
+		{Spec{Language: Python, Framework: Django, ProjectType: WebApp, Complexity: Enterprise}, "git init,python venv"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, h := range reg.Hooks(tt.spec) {
+			names = append(names, h.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.spec.Framework, got, tt.want)
+		}
+	}
+}
+
+func TestPackHooks(t *testing.T) {
+	manifest := `name: tidy
+version: 1.0.0
+language: Go
+framework: tidy
+hooks:
+  - run: [go, mod, tidy]
+    timeout: 2m
+files:
+  - path: go.mod
+    template: go.mod.tmpl
+`
+	fsys := fstest.MapFS{
+		"pack.yaml":   {Data: []byte(manifest)},
+		"go.mod.tmpl": {Data: []byte("module {{.ModulePath}}\n")},
+	}
+	p, err := ReadPack(fsys, "tidy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := p.Hooks[0]; h.Name != "go mod tidy" || h.Limit().Minutes() != 2 {
+		t.Errorf("hook = %+v, limit %s", h, h.Limit())
+	}
+
+	for _, bad := range []struct{ to, want string }{
+		{"timeout: soon", "bad timeout"},
+		{"unless: [\"\"]", "empty unless command"},
+	} {
+		fsys["pack.yaml"].Data = []byte(strings.Replace(manifest, "timeout: 2m", bad.to, 1))
+		if _, err := ReadPack(fsys, "tidy"); err == nil || !strings.Contains(err.Error(), bad.want) {
+			t.Errorf("got %v, want %q", err, bad.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsImport = regexp.MustCompile(`(?m)(?:require\(\s*|\bfrom\s+|^\s*import\s+)['"]([^'"]+)['"]`)
+	pyImport = regexp.MustCompile(`^\s*(?:from\s+([\w.]+)\s+import\b|import\s+([\w.]+(?:\s*,\s*[\w.]+)*))`)
+)
+
+// nodeBuiltins and pythonStdlib list the standard modules generated code may
+// use without declaring them.
+var (
+	nodeBuiltins = []string{
+		"assert", "buffer", "child_process", "crypto", "events", "fs", "http", "https",
+		"net", "os", "path", "process", "readline", "stream", "timers", "url", "util",
+		"worker_threads", "zlib",
+	}
+	pythonStdlib = []string{
+		"argparse", "asyncio", "collections", "contextlib", "dataclasses", "datetime",
+		"functools", "hmac", "json", "logging", "os", "pathlib", "platform", "re", "sqlite3",
+		"sys", "time", "typing", "unittest", "urllib", "uuid",
+	}
+)
+
+// CheckImports reports every third-party import in the generated sources
+// that the project's dependency manifest does not declare.
+func CheckImports(spec Spec, files []FileTemplate) error {
+	spec = spec.WithDefaults()
+	deps := spec.allDependencies()
+
+	var errs []error
+	undeclared := func(file, imp, manifest string) {
+		errs = append(errs, fmt.Errorf("%s imports %q, which %s does not declare", file, imp, manifest))
+	}
+
+	switch spec.Language {
+	case Go:
+		for _, f := range files {
+			if path.Ext(f.Path) != ".go" {
+				continue
+			}
+			imports, err := goImports(f)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, imp := range imports {
+				if !goDeclared(imp, spec.ModulePath, deps) {
+					undeclared(f.Path, imp, "go.mod")
+				}
+			}
+		}
+
+	case JS:
+		for _, f := range files {
+			if path.Ext(f.Path) != ".js" {
+				continue
+			}
+			for _, m := range jsImport.FindAllStringSubmatch(f.Content, -1) {
+				if pkg := jsPackage(m[1]); pkg != "" && !declared(pkg, deps) {
+					undeclared(f.Path, m[1], "package.json")
+				}
+			}
+		}
+
+	case Python:
+		local := pythonModules(files)
+		for _, f := range files {
+			if path.Ext(f.Path) != ".py" {
+				continue
+			}
+			for _, line := range strings.Split(f.Content, "\n") {
+				for _, mod := range pyModules(line) {
+					top, _, _ := strings.Cut(mod, ".")
+					if top == "" || slices.Contains(pythonStdlib, top) || slices.Contains(local, top) {
+						continue
+					}
+					if !declared(top, deps) {
+						undeclared(f.Path, mod, "requirements.txt")
+					}
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func goImports(f FileTemplate) ([]string, error) {
+	parsed, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Content, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	var imports []string
+	for _, spec := range parsed.Imports {
+		imp, _ := strconv.Unquote(spec.Path.Value)
+		imports = append(imports, imp)
+	}
+	return imports, nil
+}
+
+// goDeclared reports whether imp is in the standard library, the project
+// itself or one of the required modules.
+func goDeclared(imp, module string, deps []Dependency) bool {
+	first, _, _ := strings.Cut(imp, "/")
+	if !strings.Contains(first, ".") {
+		return true
+	}
+	if imp == module || strings.HasPrefix(imp, module+"/") {
+		return true
+	}
+	for _, d := range deps {
+		if imp == d.Name || strings.HasPrefix(imp, d.Name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsPackage returns the npm package an import specifier refers to, or ""
+// for relative paths and Node built-ins.
+func jsPackage(spec string) string {
+	if strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "node:") {
+		return ""
+	}
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	if slices.Contains(nodeBuiltins, parts[0]) {
+		return ""
+	}
+	return parts[0]
+}
+
+// pyModules returns the absolute modules a line of Python imports.
+// Relative imports are the project's own and are skipped.
+func pyModules(line string) []string {
+	m := pyImport.FindStringSubmatch(line)
+	switch {
+	case m == nil:
+		return nil
+	case m[1] != "":
+		if strings.HasPrefix(m[1], ".") {
+			return nil
+		}
+		return []string{m[1]}
+	}
+
+	var mods []string
+	for _, mod := range strings.Split(m[2], ",") {
+		mods = append(mods, strings.TrimSpace(mod))
+	}
+	return mods
+}
+
+// pythonModules lists the top-level packages and modules the project
+// itself defines.
+func pythonModules(files []FileTemplate) []string {
+	var mods []string
+	for _, f := range files {
+		if path.Ext(f.Path) != ".py" {
+			continue
+		}
+		top, _, nested := strings.Cut(f.Path, "/")
+		if !nested {
+			top = strings.TrimSuffix(top, ".py")
+		}
+		if !slices.Contains(mods, top) {
+			mods = append(mods, top)
+		}
+	}
+	return mods
+}
+
+func declared(name string, deps []Dependency) bool {
+	for _, d := range deps {
+		imp := d.Import
+		if imp == "" {
+			imp = d.Name
+		}
+		if name == imp {
+			return true
+		}
+	}
+	return false
+}
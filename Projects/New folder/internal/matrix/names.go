@@ -0,0 +1,55 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxAppNameLength keeps the derived names short enough for every tool
+// that sees them; PostgreSQL, for one, cuts database names at 63 bytes.
+const maxAppNameLength = 63
+
+// goReservedPaths are import path patterns the go command gives a special
+// meaning, so they cannot name a module.
+var goReservedPaths = []string{"all", "cmd", "std", "tool", "work"}
+
+// ValidateAppName checks that name can be turned into the identifiers
+// lang derives from it: the Go module path, the npm package name, or the
+// Python logger and database names.
+func ValidateAppName(lang Language, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("app name is required")
+	}
+	if first := []rune(name)[0]; !unicode.IsLetter(first) {
+		return fmt.Errorf("app name %q must start with a letter", name)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -_.", r) {
+			return fmt.Errorf("app name %q may only contain letters, digits, spaces, '-', '_' and '.'", name)
+		}
+	}
+	if len(identifier(name, '_')) > maxAppNameLength {
+		return fmt.Errorf("app name %q is too long (at most %d characters)", name, maxAppNameLength)
+	}
+
+	switch lang {
+	case Go:
+		if mod := identifier(name, '-'); slices.Contains(goReservedPaths, mod) {
+			return fmt.Errorf("app name %q would give the module path %q, which the go command reserves", name, mod)
+		}
+	case JS:
+		pkg := identifier(name, '-')
+		if slices.Contains(nodeBuiltins, pkg) {
+			return fmt.Errorf("app name %q would give the npm package name %q, which is a Node core module", name, pkg)
+		}
+	case Python:
+		if mod := identifier(name, '_'); slices.Contains(pythonStdlib, mod) {
+			return fmt.Errorf("app name %q would shadow the standard library module %q", name, mod)
+		}
+	}
+	return nil
+}
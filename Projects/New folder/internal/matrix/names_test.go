@@ -0,0 +1,32 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAppName(t *testing.T) {
+	tests := []struct {
+		lang Language
+		name string
+		want string
+	}{
+		{Go, "My Cool App", ""},
+		{JS, "web-shop.v2", ""},
+		{Python, "billing_api", ""},
+		{Go, "  ", "required"},
+		{Go, "9lives", "start with a letter"},
+		{JS, "shop/admin", "may only contain"},
+		{Go, strings.Repeat("a", 64), "too long"},
+		{Go, "std", "reserves"},
+		{JS, "HTTP", "core module"},
+		{Python, "json", "standard library"},
+		{"", "json", ""},
+	}
+	for _, tt := range tests {
+		err := ValidateAppName(tt.lang, tt.name)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s %q: got %v, want %q", tt.lang, tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pack is a user-installed template set living in its own directory, e.g.
+// ~/.config/gen-code/templates/chi-api/pack.yaml.
+type Pack struct {
+	Name         string        `json:"name" yaml:"name"`
+	Version      string        `json:"version" yaml:"version"`
+	Description  string        `json:"description" yaml:"description"`
+	Language     Language      `json:"language" yaml:"language"`
+	Framework    Framework     `json:"framework" yaml:"framework"`
+	ProjectTypes []ProjectType `json:"project_types" yaml:"project_types"`
+	Complexities []Complexity  `json:"complexities" yaml:"complexities"`
+	Files        []PackFile    `json:"files" yaml:"files"`
+	Prompts      []Prompt      `json:"prompts,omitempty" yaml:"prompts,omitempty"`
+	Hooks        []Hook        `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+
+	Dir  string `json:"-" yaml:"-"`
+	fsys fs.FS
+}
+
+// PackFile maps a template inside the pack to its output path. The optional
+// filters restrict the file to some project types or complexity levels.
+type PackFile struct {
+	Path         string        `json:"path" yaml:"path"`
+	Template     string        `json:"template" yaml:"template"`
+	ProjectTypes []ProjectType `json:"project_types,omitempty" yaml:"project_types,omitempty"`
+	Complexities []Complexity  `json:"complexities,omitempty" yaml:"complexities,omitempty"`
+	NoHeader     bool          `json:"no_header,omitempty" yaml:"no_header,omitempty"`
+
+	// Mode is the octal permission of the output, e.g. "0755" for a
+	// script; empty means 0644.
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Binary copies the template as is instead of rendering it.
+	Binary bool `json:"binary,omitempty" yaml:"binary,omitempty"`
+	// Dir makes Path an empty directory, which needs no template.
+	Dir bool `json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Link makes Path a symbolic link to this target, relative to the
+	// link's directory; it needs no template and must stay in the project.
+	Link string `json:"link,omitempty" yaml:"link,omitempty"`
+}
+
+var packManifests = []string{"pack.yaml", "pack.yml", "pack.json"}
+
+// packs holds every loaded pack keyed by language and framework.
+var packs = map[Language]map[Framework]*Pack{}
+
+// DefaultPackDir is where gen-code looks for template packs when no
+// directory is given explicitly.
+func DefaultPackDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gen-code", "templates")
+}
+
+// LoadPacks registers every pack found directly under dir. Broken packs and
+// packs that clash with a framework that is already known are skipped; each
+// is described in the returned warnings so the caller can report it. A
+// missing directory is not an error.
+func LoadPacks(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template packs in %s: %w", dir, err)
+	}
+
+	// ReadDir sorts by name, so the winner of a clash between packs is stable.
+	var warnings []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		p, err := readPack(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("template pack %s skipped: %v", entry.Name(), err))
+			continue
+		}
+
+		if err := registerPack(p); err != nil {
+			warnings = append(warnings, fmt.Sprintf("template pack %s skipped: %v", p.Name, err))
+		}
+	}
+
+	return warnings, nil
+}
+
+func readPack(dir string) (*Pack, error) {
+	return ReadPack(os.DirFS(dir), dir)
+}
+
+// ReadPack reads and validates the pack whose manifest and templates are
+// in fsys. dir is where the pack lives, and names it when the manifest
+// does not.
+func ReadPack(fsys fs.FS, dir string) (*Pack, error) {
+	p := &Pack{Dir: dir, fsys: fsys}
+
+	var (
+		data     []byte
+		manifest string
+		err      error
+	)
+	for _, name := range packManifests {
+		data, err = fs.ReadFile(fsys, name)
+		if err == nil {
+			manifest = name
+			break
+		}
+	}
+	if manifest == "" {
+		return nil, fmt.Errorf("no manifest found (expected one of %s)", strings.Join(packManifests, ", "))
+	}
+
+	if path.Ext(manifest) == ".json" {
+		err = json.Unmarshal(data, p)
+	} else {
+		err = yaml.Unmarshal(data, p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", manifest, err)
+	}
+
+	if p.Name == "" {
+		p.Name = filepath.Base(dir)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Pack) validate() error {
+	var errs []error
+
+	if strings.TrimSpace(string(p.Language)) == "" {
+		errs = append(errs, errors.New("language is required"))
+	} else if lang, err := registry.ParseLanguage(string(p.Language)); err == nil {
+		p.Language = lang
+	}
+
+	if strings.TrimSpace(string(p.Framework)) == "" {
+		errs = append(errs, errors.New("framework is required"))
+	}
+
+	if len(p.ProjectTypes) == 0 {
+		p.ProjectTypes = registry.projectTypeNames()
+	}
+	for i, pt := range p.ProjectTypes {
+		parsed, err := registry.ParseProjectType(string(pt))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		p.ProjectTypes[i] = parsed
+	}
+
+	if len(p.Complexities) == 0 {
+		p.Complexities = registry.complexityNames()
+	}
+	for i, c := range p.Complexities {
+		parsed, err := registry.ParseComplexity(string(c))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		p.Complexities[i] = parsed
+	}
+
+	seen := map[string]bool{}
+	for i := range p.Prompts {
+		if p.Prompts[i].Kind == "" {
+			p.Prompts[i].Kind = PromptString
+		}
+		if err := p.Prompts[i].check(); err != nil {
+			errs = append(errs, err)
+		}
+		if seen[p.Prompts[i].Name] {
+			errs = append(errs, fmt.Errorf("prompt %s is declared twice", p.Prompts[i].Name))
+		}
+		seen[p.Prompts[i].Name] = true
+	}
+
+	for i := range p.Hooks {
+		if p.Hooks[i].Name == "" {
+			p.Hooks[i].Name = strings.Join(p.Hooks[i].Run, " ")
+		}
+		if err := p.Hooks[i].check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(p.Files) == 0 {
+		errs = append(errs, errors.New("no files declared"))
+	}
+	for _, f := range p.Files {
+		if f.Path == "" || (f.Template == "" && !f.Dir && f.Link == "") {
+			errs = append(errs, errors.New("every file needs both a path and a template, unless it is a dir or a link"))
+			continue
+		}
+		if !filepath.IsLocal(f.Path) {
+			errs = append(errs, fmt.Errorf("file path %q must stay inside the project", f.Path))
+		}
+		if f.Dir && (f.Template != "" || f.Binary || f.Mode != "") {
+			errs = append(errs, fmt.Errorf("dir %s cannot have a template, binary or mode", f.Path))
+			continue
+		}
+		if f.Link != "" {
+			if f.Dir || f.Template != "" || f.Binary || f.Mode != "" {
+				errs = append(errs, fmt.Errorf("link %s cannot have a template, binary, mode or dir", f.Path))
+			} else if !LinkInside(filepath.ToSlash(f.Path), f.Link) {
+				errs = append(errs, fmt.Errorf("link %s points to %q, outside the project", f.Path, f.Link))
+			}
+			continue
+		}
+		if f.Template != "" {
+			if _, err := fs.Stat(p.fsys, f.Template); err != nil {
+				errs = append(errs, fmt.Errorf("template %s not found in pack", f.Template))
+			}
+		}
+		if _, err := parseMode(f.Mode); err != nil {
+			errs = append(errs, fmt.Errorf("file %s: %w", f.Path, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func registerPack(p *Pack) error {
+	err := registry.Register(FrameworkInfo{
+		Name:         p.Framework,
+		Language:     p.Language,
+		Description:  p.Description,
+		ProjectTypes: p.ProjectTypes,
+		Complexities: p.Complexities,
+		Pack:         p.Name,
+		Prompts:      p.Prompts,
+		Hooks:        p.Hooks,
+	})
+	if err != nil {
+		return err
+	}
+
+	if packs[p.Language] == nil {
+		packs[p.Language] = map[Framework]*Pack{}
+	}
+	packs[p.Language][p.Framework] = p
+	return nil
+}
+
+func lookupPack(lang Language, fw Framework) *Pack {
+	return packs[lang][fw]
+}
+
+// TemplateVersion identifies the built-in templates. Bump it with every
+// template change that generated projects should pick up through
+// gen-code upgrade.
+const TemplateVersion = "2026.10.2"
+
+// TemplateSource names the templates spec is rendered from, "builtin" or
+// "pack <name>", and their version.
+func TemplateSource(spec Spec) (source, version string) {
+	if p := lookupPack(spec.Language, spec.Framework); p != nil {
+		return "pack " + p.Name, p.Version
+	}
+	return "builtin", TemplateVersion
+}
+
+func (p *Pack) render(spec Spec) ([]FileTemplate, error) {
+	if !slices.Contains(p.ProjectTypes, spec.ProjectType) {
+		return nil, fmt.Errorf("template pack %s does not support %s projects", p.Name, spec.ProjectType)
+	}
+	if !slices.Contains(p.Complexities, spec.Complexity) {
+		return nil, fmt.Errorf("template pack %s does not support %s complexity", p.Name, spec.Complexity)
+	}
+
+	spec.prompts = selectPrompts(p.Prompts, spec)
+	var files []FileTemplate
+	for _, f := range p.Files {
+		if len(f.ProjectTypes) > 0 && !slices.Contains(f.ProjectTypes, spec.ProjectType) {
+			continue
+		}
+		if len(f.Complexities) > 0 && !slices.Contains(f.Complexities, spec.Complexity) {
+			continue
+		}
+
+		out, err := p.filePath(f.Path, spec)
+		if err != nil {
+			return nil, err
+		}
+		if f.Dir {
+			files = append(files, FileTemplate{Path: out, Dir: true})
+			continue
+		}
+		if f.Link != "" {
+			files = append(files, FileTemplate{Path: out, Link: f.Link})
+			continue
+		}
+
+		var content string
+		if f.Binary {
+			var raw []byte
+			raw, err = fs.ReadFile(p.fsys, f.Template)
+			content = string(raw)
+		} else {
+			content, err = renderFrom(p.fsys, f.Template, spec)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("template pack %s: %w", p.Name, err)
+		}
+		mode, _ := parseMode(f.Mode)
+		files = append(files, FileTemplate{Path: out, Content: content, Mode: mode, NoHeader: f.NoHeader || f.Binary})
+	}
+	return files, nil
+}
+
+// parseMode reads an octal permission such as 0755; empty is zero, the
+// default.
+func parseMode(s string) (fs.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || n > 0o777 {
+		return 0, fmt.Errorf("mode %q is not an octal permission such as 0755", s)
+	}
+	return fs.FileMode(n), nil
+}
+
+// filePath renders a file path, which may use the template variables too,
+// e.g. cmd/{{kebab .AppName}}/main.go.
+func (p *Pack) filePath(raw string, spec Spec) (string, error) {
+	if !strings.Contains(raw, "{{") {
+		return filepath.ToSlash(raw), nil
+	}
+	t, err := template.New(raw).Funcs(templateFuncs).Option("missingkey=error").Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("template pack %s: bad file path %q: %w", p.Name, raw, err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, spec); err != nil {
+		return "", fmt.Errorf("template pack %s: bad file path %q: %w", p.Name, raw, err)
+	}
+	out := filepath.ToSlash(b.String())
+	if !filepath.IsLocal(out) {
+		return "", fmt.Errorf("template pack %s: file path %q renders to %q, outside the project", p.Name, raw, out)
+	}
+	return out, nil
+}
+
+// Check renders the pack for every project type and complexity it
+// supports, so that broken templates show up before anyone generates from
+// the pack.
+func (p *Pack) Check() error {
+	var errs []error
+	for _, pt := range p.ProjectTypes {
+		for _, c := range p.Complexities {
+			spec := Spec{AppName: "Demo App", Language: p.Language, Framework: p.Framework, ProjectType: pt, Complexity: c}
+			if _, err := p.render(spec.WithDefaults()); err != nil {
+				errs = append(errs, fmt.Errorf("%s, %s: %w", pt, c, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package matrix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPackFileKinds(t *testing.T) {
+	manifest := `name: tools
+version: 1.0.0
+language: Go
+framework: tools
+files:
+  - path: run.sh
+    template: run.sh.tmpl
+    mode: "0755"
+  - path: logo.png
+    template: logo.png
+    binary: true
+  - path: data
+    dir: true
+  - path: docs/run.sh
+    link: ../run.sh
+`
+	fsys := fstest.MapFS{
+		"pack.yaml":   {Data: []byte(manifest)},
+		"run.sh.tmpl": {Data: []byte("#!/bin/sh\necho {{.AppName}}\n")},
+		"logo.png":    {Data: []byte("\x89PNG{{")},
+	}
+	p, err := ReadPack(fsys, "tools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := p.render(Spec{AppName: "demo", Language: Go, Framework: "tools", ProjectType: CLI, Complexity: Minimal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 {
+		t.Fatalf("got %d files", len(files))
+	}
+	if run := files[0]; run.Mode != 0755 || run.Content != "#!/bin/sh\necho demo\n" {
+		t.Errorf("run.sh: mode %v, content %q", run.Mode, run.Content)
+	}
+	if logo := files[1]; logo.Content != "\x89PNG{{" || !logo.NoHeader {
+		t.Errorf("logo.png was rendered or gets a header: %q", logo.Content)
+	}
+	if data := files[2]; !data.Dir || data.Path != "data" {
+		t.Errorf("data = %+v, want a dir marker", data)
+	}
+	if link := files[3]; link.Link != "../run.sh" || link.Content != "" {
+		t.Errorf("docs/run.sh = %+v, want a link", link)
+	}
+
+	for _, bad := range []struct{ from, to, want string }{
+		{`mode: "0755"`, `mode: "0999"`, "not an octal permission"},
+		{"dir: true", "dir: true\n    mode: \"0755\"", "cannot have a template, binary or mode"},
+		{"link: ../run.sh", "link: ../../etc/passwd", "outside the project"},
+		{"link: ../run.sh", "link: /etc/passwd", "outside the project"},
+		{"link: ../run.sh", "link: ../run.sh\n    binary: true", "cannot have a template, binary, mode or dir"},
+	} {
+		fsys["pack.yaml"].Data = []byte(strings.Replace(manifest, bad.from, bad.to, 1))
+		if _, err := ReadPack(fsys, "tools"); err == nil || !strings.Contains(err.Error(), bad.want) {
+			t.Errorf("got %v, want %q", err, bad.want)
+		}
+	}
+}
+
+func TestLoadPacks(t *testing.T) {
+	saved, savedPacks := registry, packs
+	registry, packs = NewRegistry(), map[Language]map[Framework]*Pack{}
+	t.Cleanup(func() { registry, packs = saved, savedPacks })
+
+	dir := t.TempDir()
+	write := func(pack, manifest string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, pack), 0755); err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]string{"pack.yaml": manifest, "main.go.tmpl": "package main\n"}
+		for name, data := range files {
+			if err := os.WriteFile(filepath.Join(dir, pack, name), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	manifest := func(name, lang, framework string) string {
+		return "name: " + name + "\nversion: 1.0.0\nlanguage: " + lang + "\nframework: " + framework +
+			"\nfiles:\n  - path: main.go\n    template: main.go.tmpl\n"
+	}
+	// Packs load in directory order, so a-chi wins the clash with b-chi.
+	write("a-chi", manifest("a-chi", "Go", "Chi"))
+	write("b-chi", manifest("b-chi", "Go", "chi"))
+	write("gin", manifest("gin", "Go", "Gin"))
+	write("rust", manifest("rust", "Rust", "Axum"))
+	write("broken", "name: [")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings, err := LoadPacks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"template pack b-chi skipped: Go framework \"Chi\" is already provided by pack a-chi",
+		"template pack broken skipped",
+		"template pack gin skipped: Go framework \"Gin\" is already built in",
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("warnings = %q", warnings)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(warnings[i], w) {
+			t.Errorf("warning %d = %q, want %q", i, warnings[i], w)
+		}
+	}
+
+	if chi, ok := registry.Framework(Go, "chi"); !ok || chi.Pack != "a-chi" {
+		t.Errorf("Chi = %+v, want it from a-chi", chi)
+	}
+	if gin, ok := registry.Framework(Go, "Gin"); !ok || gin.Pack != "" {
+		t.Errorf("Gin = %+v, want the built-in one", gin)
+	}
+	if axum, ok := registry.Framework("Rust", "Axum"); !ok || axum.Pack != "rust" || len(axum.ProjectTypes) == 0 {
+		t.Errorf("Axum = %+v, want it from rust with every project type", axum)
+	}
+	if _, ok := registry.language("Rust"); !ok {
+		t.Error("the Rust language from a pack is not listed")
+	}
+	if source, version := TemplateSource(Spec{Language: Go, Framework: "Chi"}); source != "pack a-chi" || version != "1.0.0" {
+		t.Errorf("Chi renders from %s %s", source, version)
+	}
+
+	if warnings, err := LoadPacks(filepath.Join(dir, "missing")); err != nil || warnings != nil {
+		t.Errorf("missing dir: %q, %v", warnings, err)
+	}
+}
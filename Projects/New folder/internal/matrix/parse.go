@@ -0,0 +1,32 @@
+package matrix
+
+import "strings"
+
+var aliases = map[string]string{
+	"js":     string(JS),
+	"node":   string(JS),
+	"py":     string(Python),
+	"golang": string(Go),
+}
+
+// matches accepts either the full display value ("Minimal (MVP)") or its
+// leading word ("minimal"), ignoring case, so flags stay short to type.
+func matches(input, value string) bool {
+	input = strings.TrimSpace(input)
+	if alias, ok := aliases[strings.ToLower(input)]; ok {
+		input = alias
+	}
+	if strings.EqualFold(input, value) {
+		return true
+	}
+	short, _, _ := strings.Cut(value, " ")
+	return strings.EqualFold(input, short)
+}
+
+func join[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = string(v)
+	}
+	return strings.Join(names, ", ")
+}
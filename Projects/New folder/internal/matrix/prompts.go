@@ -0,0 +1,307 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromptKind is the type of answer a prompt takes.
+type PromptKind string
+
+const (
+	PromptString PromptKind = "string"
+	PromptInt    PromptKind = "int"
+	PromptBool   PromptKind = "bool"
+	PromptChoice PromptKind = "choice"
+)
+
+var promptKinds = []PromptKind{PromptString, PromptInt, PromptBool, PromptChoice}
+
+// Prompt is an extra question a stack asks after the add-ons. Answers are
+// kept in Spec.Vars as text and templates read them, typed, with
+// {{.Var "name"}}. Unanswered prompts take their default.
+type Prompt struct {
+	Name        string     `json:"name" yaml:"name"`
+	Label       string     `json:"label" yaml:"label"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Kind        PromptKind `json:"type" yaml:"type"`
+	Default     string     `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Choices lists the answers of a choice prompt.
+	Choices []string `json:"choices,omitempty" yaml:"choices,omitempty"`
+	// Pattern is a regular expression a string answer must match in full.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Min and Max bound an int answer.
+	Min *int `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *int `json:"max,omitempty" yaml:"max,omitempty"`
+
+	// ProjectTypes and Complexities restrict where the prompt is asked;
+	// empty means everywhere.
+	ProjectTypes []ProjectType `json:"project_types,omitempty" yaml:"project_types,omitempty"`
+	Complexities []Complexity  `json:"complexities,omitempty" yaml:"complexities,omitempty"`
+
+	// Built-in prompts may depend on more of the spec, and derive their
+	// default from it.
+	when       func(Spec) bool
+	defaultFor func(Spec) string
+}
+
+// Prompts whose answers live in Spec fields of their own rather than in
+// Spec.Vars, so that templates and flags written before prompts existed
+// keep working.
+const (
+	VarPort       = "port"
+	VarModulePath = "module_path"
+)
+
+var prompts = []Prompt{
+	{Name: VarPort, Label: "HTTP Port", Description: "Port the server listens on", Kind: PromptInt, Min: ptr(1), Max: ptr(65535),
+		ProjectTypes: []ProjectType{WebApp, Backend},
+		defaultFor:   func(s Spec) string { return strconv.Itoa(defaultPort(s.Framework)) }},
+	{Name: VarModulePath, Label: "Module Path", Description: "Go module path, e.g. github.com/acme/billing", Kind: PromptString,
+		Pattern:    modulePathPattern,
+		when:       func(s Spec) bool { return s.Language == Go },
+		defaultFor: func(s Spec) string { return identifier(s.AppName, '-') }},
+	{Name: "db_name", Label: "Database Name", Description: "Database the generated config and compose file use", Kind: PromptString,
+		Pattern:    `[A-Za-z_][A-Za-z0-9_]*`,
+		when:       func(s Spec) bool { return s.Database() != "" },
+		defaultFor: func(s Spec) string { return Snake(s.AppName) }},
+	{Name: "node_version", Label: "Node.js Version", Description: "Major version for package.json engines and the Docker image", Kind: PromptChoice,
+		Choices: []string{"18", "20", "22"}, Default: "20",
+		when: func(s Spec) bool { return s.Language == JS }},
+	// Only the Docker image pins Python.
+	{Name: "python_version", Label: "Python Version", Description: "Version of the Docker base image", Kind: PromptChoice,
+		Choices: []string{"3.11", "3.12", "3.13"}, Default: "3.12",
+		when: func(s Spec) bool { return s.Language == Python && (s.Has(Docker) || s.IsEnterprise()) }},
+	{Name: "license", Label: "License", Description: "License the LICENSE file grants", Kind: PromptChoice,
+		Choices: licenses, Default: "MIT",
+		when: func(s Spec) bool { return s.Has(License) }},
+	{Name: "license_holder", Label: "License Holder", Description: "Copyright holder named in the LICENSE file", Kind: PromptString,
+		Pattern:    `.*\S.*`,
+		when:       func(s Spec) bool { return s.Has(License) },
+		defaultFor: func(s Spec) string { return s.AppName }},
+	{Name: "license_year", Label: "Copyright Year", Kind: PromptInt, Min: ptr(1970), Max: ptr(9999),
+		when:       func(s Spec) bool { return s.Has(License) },
+		defaultFor: func(Spec) string { return strconv.Itoa(time.Now().Year()) }},
+}
+
+// modulePathPattern accepts slash-separated elements that neither start
+// nor end with a dot, so no element is "." or "..".
+const modulePathPattern = `[A-Za-z0-9]([A-Za-z0-9._~-]*[A-Za-z0-9_~-])?(/[A-Za-z0-9_~-]([A-Za-z0-9._~-]*[A-Za-z0-9_~-])?)*`
+
+// ValidateModulePath checks a Go module path the way the wizard's
+// module_path prompt does.
+func ValidateModulePath(p string) error {
+	i := slices.IndexFunc(prompts, func(p Prompt) bool { return p.Name == VarModulePath })
+	_, err := prompts[i].Parse(p)
+	return err
+}
+
+// licenses are the texts under templates/shared/licenses, by SPDX id.
+var licenses = []string{"MIT", "Apache-2.0", "BSD-3-Clause", "GPL-3.0"}
+
+func ptr[T any](v T) *T { return &v }
+
+// Prompts returns the prompts asked for spec, in order: the built-in ones
+// for built-in frameworks, or the ones a pack declares.
+func (r *Registry) Prompts(spec Spec) []Prompt {
+	info, ok := r.Framework(spec.Language, string(spec.Framework))
+	if !ok {
+		return nil
+	}
+	if info.Pack != "" {
+		return selectPrompts(info.Prompts, spec)
+	}
+	return selectPrompts(prompts, spec)
+}
+
+func selectPrompts(candidates []Prompt, spec Spec) []Prompt {
+	var list []Prompt
+	for _, p := range candidates {
+		if len(p.ProjectTypes) > 0 && !slices.Contains(p.ProjectTypes, spec.ProjectType) {
+			continue
+		}
+		if len(p.Complexities) > 0 && !slices.Contains(p.Complexities, spec.Complexity) {
+			continue
+		}
+		if p.when != nil && !p.when(spec) {
+			continue
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+// validateVars checks every answer in spec.Vars against its prompt, and
+// the port and module path, which live in fields of their own.
+func (r *Registry) validateVars(spec Spec) error {
+	asked := r.Prompts(spec)
+	var errs []error
+	for _, p := range asked {
+		var err error
+		switch {
+		case p.Name == VarPort && spec.Port != 0:
+			_, err = p.Parse(strconv.Itoa(spec.Port))
+		case p.Name == VarModulePath && spec.ModulePath != "":
+			_, err = p.Parse(spec.ModulePath)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range sortedKeys(spec.Vars) {
+		i := slices.IndexFunc(asked, func(p Prompt) bool { return p.Name == name })
+		if i < 0 {
+			names := make([]string, len(asked))
+			for j, p := range asked {
+				names[j] = p.Name
+			}
+			errs = append(errs, fmt.Errorf("variable %q is not asked for this project (valid: %s)", name, strings.Join(names, ", ")))
+			continue
+		}
+		if _, err := asked[i].Parse(spec.Vars[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// DefaultFor returns the answer the prompt suggests for spec.
+func (p Prompt) DefaultFor(spec Spec) string {
+	if p.Default == "" && p.defaultFor != nil {
+		return p.defaultFor(spec)
+	}
+	return p.Default
+}
+
+// Parse checks an answer and returns it in canonical form: choices as
+// declared, booleans as true or false.
+func (p Prompt) Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch p.Kind {
+	case PromptInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a whole number, not %q", p.Label, value)
+		}
+		if (p.Min != nil && n < *p.Min) || (p.Max != nil && n > *p.Max) {
+			return "", fmt.Errorf("%s %d is out of range (%s)", p.Label, n, p.bounds())
+		}
+		return strconv.Itoa(n), nil
+	case PromptBool:
+		switch strings.ToLower(value) {
+		case "true", "yes", "y":
+			return "true", nil
+		case "false", "no", "n":
+			return "false", nil
+		}
+		return "", fmt.Errorf("%s must be yes or no, not %q", p.Label, value)
+	case PromptChoice:
+		for _, c := range p.Choices {
+			if strings.EqualFold(c, value) {
+				return c, nil
+			}
+		}
+		return "", fmt.Errorf("%s %q is not one of %s", p.Label, value, strings.Join(p.Choices, ", "))
+	}
+
+	if p.Pattern != "" && !regexp.MustCompile(`^(?:`+p.Pattern+`)$`).MatchString(value) {
+		return "", fmt.Errorf("%s %q does not match %s", p.Label, value, p.Pattern)
+	}
+	return value, nil
+}
+
+func (p Prompt) bounds() string {
+	switch {
+	case p.Min != nil && p.Max != nil:
+		return fmt.Sprintf("%d-%d", *p.Min, *p.Max)
+	case p.Min != nil:
+		return fmt.Sprintf("at least %d", *p.Min)
+	}
+	return fmt.Sprintf("at most %d", *p.Max)
+}
+
+// check validates a prompt declared by a pack.
+func (p Prompt) check() error {
+	if !isIdentifier(p.Name) {
+		return fmt.Errorf("prompt name %q must be a snake_case identifier", p.Name)
+	}
+	if p.Label == "" {
+		return fmt.Errorf("prompt %s needs a label", p.Name)
+	}
+	if !slices.Contains(promptKinds, p.Kind) {
+		return fmt.Errorf("prompt %s has unknown type %q (valid: string, int, bool, choice)", p.Name, p.Kind)
+	}
+	if p.Kind == PromptChoice && len(p.Choices) == 0 {
+		return fmt.Errorf("prompt %s needs choices", p.Name)
+	}
+	if p.Pattern != "" {
+		if _, err := regexp.Compile(p.Pattern); err != nil {
+			return fmt.Errorf("prompt %s has a bad pattern: %w", p.Name, err)
+		}
+	}
+	if p.Default == "" && p.Kind != PromptString {
+		return fmt.Errorf("prompt %s needs a default", p.Name)
+	}
+	if p.Default != "" {
+		if _, err := p.Parse(p.Default); err != nil {
+			return fmt.Errorf("prompt %s has a bad default: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+var promptName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func isIdentifier(s string) bool { return promptName.MatchString(s) }
+
+// Var returns the answer to a prompt, typed by its kind: int, bool or
+// string. Templates call it as {{.Var "db_name"}}.
+func (s Spec) Var(name string) (any, error) {
+	asked := s.prompts
+	if asked == nil {
+		asked = registry.Prompts(s)
+	}
+	i := slices.IndexFunc(asked, func(p Prompt) bool { return p.Name == name })
+	if i < 0 {
+		return nil, fmt.Errorf("variable %q is not asked for %s/%s %s projects", name, s.Language, s.Framework, s.ProjectType)
+	}
+	p := asked[i]
+
+	value, ok := s.Vars[name]
+	switch {
+	case name == VarPort && s.Port != 0:
+		value, ok = strconv.Itoa(s.Port), true
+	case name == VarModulePath && s.ModulePath != "":
+		value, ok = s.ModulePath, true
+	}
+	if !ok {
+		value = p.DefaultFor(s)
+	}
+	value, err := p.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Kind {
+	case PromptInt:
+		return strconv.Atoi(value)
+	case PromptBool:
+		return value == "true", nil
+	}
+	return value, nil
+}
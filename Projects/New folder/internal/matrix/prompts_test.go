@@ -0,0 +1,190 @@
+package matrix
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func promptNames(ps []Prompt) string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPromptsFollowTheStack(t *testing.T) {
+	reg := NewRegistry()
+	tests := []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Minimal}, "port,module_path"},
+		{Spec{Language: Go, Framework: Gin, ProjectType: CLI, Complexity: Minimal}, "module_path"},
+		{Spec{Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Standard, Addons: []Addon{Postgres}}, "port,module_path,db_name"},
+		{Spec{Language: JS, Framework: Express, ProjectType: WebApp, Complexity: Minimal}, "port,node_version"},
+		{Spec{Language: Python, Framework: Flask, ProjectType: Backend, Complexity: Minimal}, "port"},
+		{Spec{Language: Python, Framework: Flask, ProjectType: Backend, Complexity: Enterprise}, "port,python_version"},
+	}
+	for _, tt := range tests {
+		if got := promptNames(reg.Prompts(tt.spec)); got != tt.want {
+			t.Errorf("%s/%s/%s %v: got %s, want %s", tt.spec.Framework, tt.spec.ProjectType, tt.spec.Complexity, tt.spec.Addons, got, tt.want)
+		}
+	}
+}
+
+func TestVarsReachTemplates(t *testing.T) {
+	spec := Spec{AppName: "Shop", Language: JS, Framework: Express, ProjectType: Backend, Complexity: Standard,
+		Addons: []Addon{Postgres, Docker}, Vars: map[string]string{"node_version": "22", "db_name": "orders"}}
+	if err := NewRegistry().Validate(spec); err != nil {
+		t.Fatal(err)
+	}
+	m, err := GetMatrix(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	all := m.Files
+	for _, set := range m.Addons {
+		all = append(all, set.Files...)
+	}
+	for _, f := range all {
+		files[f.Path] = f.Content
+	}
+	for path, want := range map[string]string{
+		"Dockerfile":         "FROM node:22-alpine",
+		"package.json":       `"node": ">=22"`,
+		"docker-compose.yml": "POSTGRES_DB: orders",
+	} {
+		if !strings.Contains(files[path], want) {
+			t.Errorf("%s does not contain %q", path, want)
+		}
+	}
+
+	if v, err := spec.Var("node_version"); err != nil || v != "22" {
+		t.Errorf("node_version = %v, %v", v, err)
+	}
+	if v, err := spec.Var("port"); err != nil || v != 3000 {
+		t.Errorf("port = %v (%T), %v, want the default 3000", v, v, err)
+	}
+}
+
+func TestValidateVarsRejects(t *testing.T) {
+	base := Spec{AppName: "demo", Language: JS, Framework: Express, ProjectType: Backend, Complexity: Minimal}
+	tests := []struct {
+		vars map[string]string
+		want string
+	}{
+		{map[string]string{"node_version": "16"}, "not one of 18, 20, 22"},
+		{map[string]string{"python_version": "3.12"}, "is not asked"},
+		{map[string]string{"db_name": "shop"}, "is not asked"},
+	}
+	for _, tt := range tests {
+		spec := base
+		spec.Vars = tt.vars
+		err := NewRegistry().Validate(spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got %v, want %q", tt.vars, err, tt.want)
+		}
+	}
+
+	// The port and module path prompts answer into fields of their own.
+	for _, spec := range []Spec{
+		{AppName: "demo", Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Minimal, ModulePath: "bad path"},
+		{AppName: "demo", Language: Go, Framework: Gin, ProjectType: Backend, Complexity: Minimal, Port: 70000},
+	} {
+		if err := NewRegistry().Validate(spec); err == nil {
+			t.Errorf("module path %q, port %d: accepted", spec.ModulePath, spec.Port)
+		}
+	}
+}
+
+func TestPromptParse(t *testing.T) {
+	port := prompts[0]
+	if got, err := port.Parse(" 0080 "); err != nil || got != "80" {
+		t.Errorf("Parse(0080) = %q, %v", got, err)
+	}
+	if _, err := port.Parse("70000"); err == nil || !strings.Contains(err.Error(), "1-65535") {
+		t.Errorf("Parse(70000) = %v", err)
+	}
+	flag := Prompt{Name: "metrics", Label: "Metrics", Kind: PromptBool, Default: "no"}
+	if got, err := flag.Parse("Yes"); err != nil || got != "true" {
+		t.Errorf("Parse(Yes) = %q, %v", got, err)
+	}
+	if _, err := flag.Parse("maybe"); err == nil {
+		t.Error("Parse(maybe) succeeded")
+	}
+}
+
+func TestPackPrompts(t *testing.T) {
+	manifest := `name: hello
+version: 1.0.0
+language: Go
+framework: hello
+project_types: [cli]
+complexities: [minimal]
+prompts:
+  - name: greeting
+    label: Greeting
+    default: Hello
+  - name: shout
+    label: Shout
+    type: bool
+    default: "no"
+files:
+  - path: main.go
+    template: main.go.tmpl
+`
+	fsys := fstest.MapFS{
+		"pack.yaml":    {Data: []byte(manifest)},
+		"main.go.tmpl": {Data: []byte(`{{.Var "greeting"}} {{if .Var "shout"}}!{{end}}`)},
+	}
+	p, err := ReadPack(fsys, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check(); err != nil {
+		t.Fatal(err)
+	}
+	spec := Spec{AppName: "demo", Language: Go, Framework: "hello", ProjectType: CLI, Complexity: Minimal, Vars: map[string]string{"shout": "true"}}
+	files, err := p.render(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := files[0].Content; got != "Hello !" {
+		t.Errorf("rendered %q", got)
+	}
+
+	fsys["pack.yaml"].Data = []byte(strings.Replace(manifest, "type: bool", "type: date", 1))
+	if _, err := ReadPack(fsys, "hello"); err == nil || !strings.Contains(err.Error(), "unknown type") {
+		t.Errorf("got %v, want an unknown type error", err)
+	}
+}
+
+func TestLicenseFillsHolderAndYear(t *testing.T) {
+	for _, id := range licenses {
+		spec := Spec{AppName: "Shop", Language: Python, Framework: FastAPI, ProjectType: Backend, Complexity: Minimal,
+			Addons: []Addon{Readme, License}, Vars: map[string]string{"license": id, "license_holder": "ACME Inc.", "license_year": "2031"}}
+		if err := NewRegistry().Validate(spec); err != nil {
+			t.Fatal(err)
+		}
+		m, err := GetMatrix(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files := map[string]FileTemplate{}
+		for _, set := range m.Addons {
+			for _, f := range set.Files {
+				files[f.Path] = f
+			}
+		}
+		license := files["LICENSE"]
+		if !license.NoHeader || !strings.Contains(license.Content, "2031") || !strings.Contains(license.Content, "ACME Inc.") {
+			t.Errorf("%s: LICENSE lacks the year or holder, or gets a header", id)
+		}
+		if !strings.Contains(files["README.md"].Content, id+". See [LICENSE]") {
+			t.Errorf("%s: README does not name the license", id)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type LanguageInfo struct {
+	Name        Language
+	Description string
+}
+
+type FrameworkInfo struct {
+	Name        Framework
+	Language    Language
+	Description string
+
+	// ProjectTypes and Complexities are the combinations this framework can
+	// generate. The wizard hides anything not listed here.
+	ProjectTypes []ProjectType
+	Complexities []Complexity
+
+	// Pack is the name of the template pack providing the framework, empty
+	// for built-ins.
+	Pack string
+
+	// Prompts are the extra questions a pack asks. Built-in frameworks
+	// share the prompts in prompts.go.
+	Prompts []Prompt
+
+	// Hooks are the commands a pack runs after generating; built-in
+	// frameworks share the ones in hooks.go.
+	Hooks []Hook
+}
+
+type ProjectTypeInfo struct {
+	Name        ProjectType
+	Description string
+}
+
+type ComplexityInfo struct {
+	Name        Complexity
+	Description string
+}
+
+// Registry is the single list of everything the wizard can offer. Menus,
+// cursor bounds, headless validation and pack conflict checks all read it.
+type Registry struct {
+	languages    []LanguageInfo
+	frameworks   map[Language][]FrameworkInfo
+	projectTypes []ProjectTypeInfo
+	complexities []ComplexityInfo
+}
+
+var registry = NewRegistry()
+
+// DefaultRegistry returns the process-wide registry, including any packs
+// loaded with LoadPacks.
+func DefaultRegistry() *Registry {
+	return registry
+}
+
+// NewRegistry returns a registry holding only the built-in stacks.
+func NewRegistry() *Registry {
+	r := &Registry{
+		languages: []LanguageInfo{
+			{Go, "Compiled, statically typed, great for services and CLIs"},
+			{JS, "Node.js runtime with the npm ecosystem"},
+			{Python, "Batteries included, quick to iterate"},
+		},
+		projectTypes: []ProjectTypeInfo{
+			{WebApp, "Server-rendered pages with static assets"},
+			{CLI, "Command-line tool with subcommands and flags"},
+			{Backend, "JSON API with configuration and health checks"},
+		},
+		complexities: []ComplexityInfo{
+			{Minimal, "Just what the project type needs to run"},
+			{Standard, "Service and repository layers"},
+			{Enterprise, "Layered, containerised and ready to operate"},
+		},
+		frameworks: map[Language][]FrameworkInfo{},
+	}
+
+	allTypes := []ProjectType{WebApp, CLI, Backend}
+	webTypes := []ProjectType{WebApp, Backend}
+
+	for _, fw := range []FrameworkInfo{
+		{Name: Gin, Language: Go, Description: "Fast HTTP router with middleware", ProjectTypes: allTypes},
+		{Name: Echo, Language: Go, Description: "Minimalist, extensible web framework", ProjectTypes: allTypes},
+		{Name: Fiber, Language: Go, Description: "Express-inspired, built on fasthttp", ProjectTypes: allTypes},
+		{Name: Express, Language: JS, Description: "The de facto Node.js web framework", ProjectTypes: allTypes},
+		{Name: Fastify, Language: JS, Description: "Low overhead, schema-first Node.js framework", ProjectTypes: allTypes},
+		{Name: Flask, Language: Python, Description: "Lightweight WSGI micro-framework", ProjectTypes: allTypes},
+		{Name: FastAPI, Language: Python, Description: "Async APIs with type-hint validation", ProjectTypes: allTypes},
+		// Django already ships its own command runner (manage.py), so it
+		// is only offered for server projects.
+		{Name: Django, Language: Python, Description: "Full-stack framework with ORM and admin", ProjectTypes: webTypes},
+	} {
+		if err := r.Register(fw); err != nil {
+			panic(err)
+		}
+	}
+
+	return r
+}
+
+// Register adds a framework, adding its language too if it is new. It fails
+// when the language already has a framework with the same name.
+func (r *Registry) Register(fw FrameworkInfo) error {
+	if existing, ok := r.Framework(fw.Language, string(fw.Name)); ok {
+		if existing.Pack != "" {
+			return fmt.Errorf("%s framework %q is already provided by pack %s", fw.Language, existing.Name, existing.Pack)
+		}
+		return fmt.Errorf("%s framework %q is already built in", fw.Language, existing.Name)
+	}
+
+	if _, ok := r.language(string(fw.Language)); !ok {
+		r.languages = append(r.languages, LanguageInfo{Name: fw.Language, Description: "Provided by template packs"})
+	}
+	if len(fw.ProjectTypes) == 0 {
+		fw.ProjectTypes = r.projectTypeNames()
+	}
+	if len(fw.Complexities) == 0 {
+		fw.Complexities = r.complexityNames()
+	}
+
+	r.frameworks[fw.Language] = append(r.frameworks[fw.Language], fw)
+	return nil
+}
+
+func (r *Registry) Languages() []LanguageInfo {
+	return r.languages
+}
+
+func (r *Registry) Frameworks(lang Language) []FrameworkInfo {
+	return r.frameworks[lang]
+}
+
+// Framework looks a framework up by name, ignoring case.
+func (r *Registry) Framework(lang Language, name string) (FrameworkInfo, bool) {
+	for _, fw := range r.frameworks[lang] {
+		if matches(name, string(fw.Name)) {
+			return fw, true
+		}
+	}
+	return FrameworkInfo{}, false
+}
+
+// ProjectTypes returns the project types the framework supports, in menu order.
+func (r *Registry) ProjectTypes(lang Language, fw Framework) []ProjectTypeInfo {
+	info, _ := r.Framework(lang, string(fw))
+
+	var list []ProjectTypeInfo
+	for _, pt := range r.projectTypes {
+		if slices.Contains(info.ProjectTypes, pt.Name) {
+			list = append(list, pt)
+		}
+	}
+	return list
+}
+
+// Complexities returns the complexity levels the framework supports, in menu order.
+func (r *Registry) Complexities(lang Language, fw Framework) []ComplexityInfo {
+	info, _ := r.Framework(lang, string(fw))
+
+	var list []ComplexityInfo
+	for _, c := range r.complexities {
+		if slices.Contains(info.Complexities, c.Name) {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+func (r *Registry) ParseLanguage(s string) (Language, error) {
+	if l, ok := r.language(s); ok {
+		return l.Name, nil
+	}
+	names := make([]string, len(r.languages))
+	for i, l := range r.languages {
+		names[i] = string(l.Name)
+	}
+	return "", fmt.Errorf("unknown language %q (valid: %s)", s, strings.Join(names, ", "))
+}
+
+func (r *Registry) ParseFramework(lang Language, s string) (Framework, error) {
+	if fw, ok := r.Framework(lang, s); ok {
+		return fw.Name, nil
+	}
+	names := make([]string, 0, len(r.frameworks[lang]))
+	for _, fw := range r.frameworks[lang] {
+		names = append(names, string(fw.Name))
+	}
+	return "", fmt.Errorf("unknown framework %q for %s (valid: %s)", s, lang, strings.Join(names, ", "))
+}
+
+func (r *Registry) ParseProjectType(s string) (ProjectType, error) {
+	for _, pt := range r.projectTypes {
+		if matches(s, string(pt.Name)) {
+			return pt.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown project type %q (valid: %s)", s, join(r.projectTypeNames()))
+}
+
+func (r *Registry) ParseComplexity(s string) (Complexity, error) {
+	for _, c := range r.complexities {
+		if matches(s, string(c.Name)) {
+			return c.Name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown complexity %q (valid: %s)", s, join(r.complexityNames()))
+}
+
+// Validate checks that the combination in spec is one the registry offers.
+// The individual values are expected to be canonical already.
+func (r *Registry) Validate(spec Spec) error {
+	fw, ok := r.Framework(spec.Language, string(spec.Framework))
+	if !ok {
+		return fmt.Errorf("unknown framework %q for %s", spec.Framework, spec.Language)
+	}
+
+	var errs []error
+	if !slices.Contains(fw.ProjectTypes, spec.ProjectType) {
+		errs = append(errs, fmt.Errorf("%s does not support %s projects (supported: %s)", fw.Name, spec.ProjectType, join(fw.ProjectTypes)))
+	}
+	if !slices.Contains(fw.Complexities, spec.Complexity) {
+		errs = append(errs, fmt.Errorf("%s does not support %s complexity (supported: %s)", fw.Name, spec.Complexity, join(fw.Complexities)))
+	}
+	if err := r.validateAddons(spec); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateContract(spec, fw); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateEntities(spec, fw); err != nil {
+		errs = append(errs, err)
+	}
+	if err := r.validateVars(spec); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) language(s string) (LanguageInfo, bool) {
+	for _, l := range r.languages {
+		if matches(s, string(l.Name)) {
+			return l, true
+		}
+	}
+	return LanguageInfo{}, false
+}
+
+func (r *Registry) projectTypeNames() []ProjectType {
+	names := make([]ProjectType, len(r.projectTypes))
+	for i, pt := range r.projectTypes {
+		names[i] = pt.Name
+	}
+	return names
+}
+
+func (r *Registry) complexityNames() []Complexity {
+	names := make([]Complexity, len(r.complexities))
+	for i, c := range r.complexities {
+		names[i] = c.Name
+	}
+	return names
+}
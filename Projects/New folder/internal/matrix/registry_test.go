@@ -0,0 +1,53 @@
+package matrix
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRegistryMenus(t *testing.T) {
+	reg := NewRegistry()
+	all := fmt.Sprint([]ProjectType{WebApp, CLI, Backend})
+	levels := fmt.Sprint([]Complexity{Minimal, Standard, Enterprise})
+	tests := []struct {
+		lang       Language
+		frameworks string
+		types      map[Framework]string
+	}{
+		{Go, fmt.Sprint([]Framework{Gin, Echo, Fiber}), map[Framework]string{Gin: all, Echo: all, Fiber: all}},
+		{JS, fmt.Sprint([]Framework{Express, Fastify}), map[Framework]string{Express: all, Fastify: all}},
+		{Python, fmt.Sprint([]Framework{Flask, FastAPI, Django}), map[Framework]string{Flask: all, FastAPI: all, Django: fmt.Sprint([]ProjectType{WebApp, Backend})}},
+	}
+	for _, tt := range tests {
+		var fws []Framework
+		for _, fw := range reg.Frameworks(tt.lang) {
+			fws = append(fws, fw.Name)
+		}
+		if got := fmt.Sprint(fws); got != tt.frameworks {
+			t.Errorf("%s frameworks: got %s, want %s", tt.lang, got, tt.frameworks)
+		}
+		for fw, want := range tt.types {
+			var types []ProjectType
+			for _, pt := range reg.ProjectTypes(tt.lang, fw) {
+				types = append(types, pt.Name)
+			}
+			if got := fmt.Sprint(types); got != want {
+				t.Errorf("%s project types: got %s, want %s", fw, got, want)
+			}
+			var cs []Complexity
+			for _, c := range reg.Complexities(tt.lang, fw) {
+				cs = append(cs, c.Name)
+			}
+			if got := fmt.Sprint(cs); got != levels {
+				t.Errorf("%s complexities: got %s, want %s", fw, got, levels)
+			}
+		}
+	}
+
+	if fws := reg.Frameworks("Rust"); len(fws) != 0 {
+		t.Errorf("Rust frameworks: %v", fws)
+	}
+	if types := reg.ProjectTypes(Go, Flask); len(types) != 0 {
+		t.Errorf("Flask under Go offers %v", types)
+	}
+}
@@ -0,0 +1,120 @@
+package matrix
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed all:templates
+var templateFS embed.FS
+
+var templateFuncs = template.FuncMap{
+	"snake": Snake,
+	"kebab": Kebab,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v any) (string, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	},
+}
+
+// Snake and Kebab are the snake and kebab template helpers.
+func Snake(s string) string { return identifier(s, '_') }
+func Kebab(s string) string { return identifier(s, '-') }
+
+// identifier lowercases s and collapses every run of non-alphanumeric
+// characters into sep, e.g. "My Cool App" -> "my_cool_app".
+func identifier(s string, sep rune) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pending && b.Len() > 0 {
+				b.WriteRune(sep)
+			}
+			pending = false
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		pending = true
+	}
+	if b.Len() == 0 {
+		return "app"
+	}
+	return b.String()
+}
+
+// tree returns one FileTemplate per template below templates/<dir>. Output
+// paths mirror the layout under dir with the .tmpl suffix dropped, so a
+// whole layer of a project can be added with one call.
+func tree(dir string) []FileTemplate {
+	var files []FileTemplate
+	root := "templates/" + dir
+
+	err := fs.WalkDir(templateFS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		files = append(files, FileTemplate{
+			Path:     strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl"),
+			Template: strings.TrimPrefix(p, "templates/"),
+		})
+		return nil
+	})
+	if err != nil {
+		// The templates are compiled in, so this is a programming error.
+		panic(fmt.Sprintf("matrix: bad template tree %s: %v", dir, err))
+	}
+
+	return files
+}
+
+// under moves files into dir, for trees shared by projects with different
+// package layouts.
+func under(dir string, files []FileTemplate) []FileTemplate {
+	for i := range files {
+		files[i].Path = dir + "/" + files[i].Path
+	}
+	return files
+}
+
+// staticAssets places the shared stylesheet, script and icon under dir,
+// with an empty img directory for the project's own images.
+func staticAssets(dir string) []FileTemplate {
+	return []FileTemplate{
+		{Path: dir + "/css/style.css", Template: "shared/static/css/style.css.tmpl"},
+		{Path: dir + "/js/app.js", Template: "shared/static/js/app.js.tmpl"},
+		{Path: dir + "/favicon.ico", Asset: "shared/static/favicon.ico"},
+		{Path: dir + "/img", Dir: true},
+	}
+}
+
+// renderTemplate renders a built-in template. data is a Spec, or a Piece
+// for the generators behind gen-code add.
+func renderTemplate(name string, data any) (string, error) {
+	return renderFrom(templateFS, "templates/"+name, data)
+}
+
+func renderFrom(fsys fs.FS, name string, data any) (string, error) {
+	raw, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", fmt.Errorf("template %s not found: %w", name, err)
+	}
+
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return b.String(), nil
+}
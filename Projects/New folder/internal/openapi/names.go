@@ -0,0 +1,661 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// words splits a name into lower-case words at punctuation and case
+// changes, e.g. "showPetByID" -> show pet by id.
+func words(s string) []string {
+	var (
+		list []string
+		cur  []rune
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			list = append(list, string(cur))
+			cur = nil
+		}
+	}
+	rs := []rune(s)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := rs[i-1]
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				flush()
+			}
+		}
+		cur = append(cur, unicode.ToLower(r))
+	}
+	flush()
+	return list
+}
+
+// initialisms are written in upper case in Go names, as golint asks.
+var initialisms = []string{"api", "cpu", "html", "http", "https", "id", "ip", "json", "sql", "ttl", "uri", "url", "uuid", "xml"}
+
+// goName joins words into an exported Go name. Type names use it in every
+// language so a schema has one name throughout.
+func goName(w []string) string {
+	var b strings.Builder
+	for _, word := range w {
+		if slices.Contains(initialisms, word) {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+func camelName(w []string) string {
+	if len(w) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(w[0])
+	for _, word := range w[1:] {
+		r := []rune(word)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "n" + name
+	}
+	return name
+}
+
+// typeName is the name a component schema gets in generated code.
+func typeName(component string) string {
+	if name := goName(words(component)); name != "" {
+		return name
+	}
+	return "Schema"
+}
+
+// Go
+
+// GoHandler names the server method that handles the operation.
+func (op Operation) GoHandler() string { return "handle" + goName(words(op.ID)) }
+
+// GoPath is the path in the :name syntax the Go routers use.
+func (op Operation) GoPath() string { return pathParam.ReplaceAllString(op.Path, ":$1") }
+
+// MethodTitle is the method as Fiber spells its route functions, e.g. Get.
+func (op Operation) MethodTitle() string {
+	return op.Method[:1] + strings.ToLower(op.Method[1:])
+}
+
+func (f Field) GoName() string {
+	if name := goName(words(f.Name)); name != "" {
+		return name
+	}
+	return "Field"
+}
+
+// Pointer reports whether the Go field is a pointer: optional numbers,
+// booleans and objects, whose zero value could be a real value.
+func (f Field) Pointer() bool {
+	if f.Required {
+		return false
+	}
+	switch f.Type.Kind {
+	case Integer, Number, Boolean:
+		return true
+	case Object:
+		return f.Type.Ref != ""
+	}
+	return false
+}
+
+func (f Field) GoType() string {
+	if f.Pointer() {
+		return "*" + f.Type.Go()
+	}
+	return f.Type.Go()
+}
+
+func (f Field) GoTag() string {
+	if f.Required {
+		return fmt.Sprintf("`json:%q`", f.Name)
+	}
+	return fmt.Sprintf("`json:%q`", f.Name+",omitempty")
+}
+
+// Go is the type in the package that declares the schemas.
+func (t Type) Go() string { return t.GoIn("") }
+
+// GoIn is the type as seen from another package, with schema names
+// qualified by pkg.
+func (t Type) GoIn(pkg string) string {
+	switch t.Kind {
+	case String:
+		return "string"
+	case Integer:
+		return "int64"
+	case Number:
+		return "float64"
+	case Boolean:
+		return "bool"
+	case Array:
+		return "[]" + t.Items.GoIn(pkg)
+	case Object:
+		if t.Values != nil {
+			return "map[string]" + t.Values.GoIn(pkg)
+		}
+		if t.Ref == "" {
+			return "map[string]any"
+		}
+		if pkg != "" {
+			return pkg + "." + t.Ref
+		}
+		return t.Ref
+	}
+	return "any"
+}
+
+// GoValidate is the call that validates v, a value of this type, or ""
+// when there is nothing to check. Schema names are qualified by pkg.
+func (t Type) GoValidate(v, pkg string) string {
+	if pkg != "" {
+		pkg += "."
+	}
+	switch {
+	case t.Ref != "":
+		return v + ".Validate()"
+	case t.Kind == Array && t.Items.Ref != "":
+		return pkg + "Each(" + v + ")"
+	}
+	return ""
+}
+
+// GoParse is the strconv call that reads a parameter of this type from
+// raw.
+func (t Type) GoParse() string {
+	switch t.Kind {
+	case Integer:
+		return "strconv.ParseInt(raw, 10, 64)"
+	case Number:
+		return "strconv.ParseFloat(raw, 64)"
+	case Boolean:
+		return "strconv.ParseBool(raw)"
+	}
+	return ""
+}
+
+// Article names the type in error messages, e.g. "an integer".
+func (t Type) Article() string {
+	switch t.Kind {
+	case Integer:
+		return "an integer"
+	case Number:
+		return "a number"
+	case Boolean:
+		return "a boolean"
+	case Array:
+		return "an array"
+	case Object:
+		return "an object"
+	}
+	return "a " + string(t.Kind)
+}
+
+// GoCheck is one constraint of a field as Go code: when Cond holds, the
+// value breaks the constraint and Message says how. Nested checks validate
+// the field's own schema instead; Each does that for every element, named
+// by its key when Keyed.
+type GoCheck struct {
+	Cond    string
+	Message string
+	Nested  string
+	Each    string
+	Keyed   bool
+	Field   string
+}
+
+// GoChecks lists the checks for the field of s; the value being checked
+// is v.
+func (s Schema) GoChecks(f Field) []GoCheck {
+	v := "v." + f.GoName()
+	deref := v
+	if f.Pointer() {
+		deref = "*" + v
+	}
+	var checks []GoCheck
+	add := func(cond, format string, args ...any) {
+		checks = append(checks, GoCheck{Cond: cond, Message: f.Name + " " + fmt.Sprintf(format, args...)})
+	}
+	guard := func(cond string) string {
+		if f.Pointer() {
+			return v + " != nil && " + cond
+		}
+		return cond
+	}
+
+	t := f.Type
+	switch t.Kind {
+	case String:
+		if f.Required {
+			add(v+` == ""`, "is required")
+		}
+		if n := t.MinLength; n != nil {
+			add(fmt.Sprintf(`%s != "" && len([]rune(%s)) < %d`, v, v, *n), "must be at least %d %s long", *n, plural(*n, "character"))
+		}
+		if n := t.MaxLength; n != nil {
+			add(fmt.Sprintf(`len([]rune(%s)) > %d`, v, *n), "must be at most %d %s long", *n, plural(*n, "character"))
+		}
+		if t.Pattern != "" {
+			add(fmt.Sprintf(`%s != "" && !%s.MatchString(%s)`, v, s.PatternVar(f), v), "must match %s", t.Pattern)
+		}
+		if len(t.Enum) > 0 {
+			quoted := make([]string, len(t.Enum))
+			for i, e := range t.Enum {
+				quoted[i] = strconv.Quote(e)
+			}
+			add(fmt.Sprintf(`%s != "" && !slices.Contains([]string{%s}, %s)`, v, strings.Join(quoted, ", "), v), "must be one of %s", strings.Join(t.Enum, ", "))
+		}
+	case Integer, Number:
+		if n := t.Minimum; n != nil {
+			add(guard(fmt.Sprintf("%s < %s", deref, bound(t.Kind, *n, math.Ceil))), "must be at least %s", formatNumber(*n))
+		}
+		if n := t.Maximum; n != nil {
+			add(guard(fmt.Sprintf("%s > %s", deref, bound(t.Kind, *n, math.Floor))), "must be at most %s", formatNumber(*n))
+		}
+	case Array:
+		if f.Required {
+			add(v+" == nil", "is required")
+		}
+		if n := t.MinItems; n != nil {
+			add(fmt.Sprintf("len(%s) < %d", v, *n), "must have at least %d %s", *n, plural(*n, "item"))
+		}
+		if n := t.MaxItems; n != nil {
+			add(fmt.Sprintf("len(%s) > %d", v, *n), "must have at most %d %s", *n, plural(*n, "item"))
+		}
+		if t.Items.Kind == Object && t.Items.Ref != "" {
+			checks = append(checks, GoCheck{Each: v, Field: f.Name})
+		}
+	case Object:
+		if t.Values != nil && t.Values.Kind == Object && t.Values.Ref != "" {
+			checks = append(checks, GoCheck{Each: v, Keyed: true, Field: f.Name})
+		}
+		if t.Ref != "" {
+			check := GoCheck{Nested: v, Field: f.Name}
+			if f.Pointer() {
+				check.Cond = v + " != nil"
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// GoValidates reports whether any field of s has a constraint to check.
+func (s Schema) GoValidates() bool {
+	return slices.ContainsFunc(s.Fields, func(f Field) bool { return len(s.GoChecks(f)) > 0 })
+}
+
+// PatternVar names the package variable holding the compiled pattern of
+// the field of s.
+func (s Schema) PatternVar(f Field) string {
+	return camelName(words(s.Name)) + f.GoName() + "Pattern"
+}
+
+// GoImports lists the standard library packages the Go declarations of
+// the schemas and parameters use.
+func (api *API) GoImports() []string {
+	var imports []string
+	use := func(pkg string) {
+		if !slices.Contains(imports, pkg) {
+			imports = append(imports, pkg)
+		}
+	}
+
+	schemas := slices.Clone(api.Schemas)
+	for _, op := range api.Operations {
+		if op.Params != nil {
+			use("errors")
+			schemas = append(schemas, *op.Params)
+			for _, f := range op.Params.Fields {
+				if f.Type.Kind != String {
+					use("strconv")
+				}
+			}
+		}
+	}
+	if len(api.Schemas) > 0 {
+		use("fmt")
+	}
+	for _, s := range schemas {
+		if s.GoValidates() {
+			use("errors")
+		}
+		for _, f := range s.Fields {
+			for _, c := range s.GoChecks(f) {
+				switch {
+				case c.Nested != "" || c.Each != "":
+					use("fmt")
+				case strings.Contains(c.Cond, "slices."):
+					use("slices")
+				case strings.Contains(c.Cond, "Pattern."):
+					use("regexp")
+				}
+			}
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
+
+// GoPattern is a field pattern compiled once into a package variable.
+type GoPattern struct {
+	Var     string
+	Pattern string
+}
+
+// Patterns lists the field patterns of the schemas and parameters.
+func (api *API) Patterns() []GoPattern {
+	var list []GoPattern
+	schemas := slices.Clone(api.Schemas)
+	for _, op := range api.Operations {
+		if op.Params != nil {
+			schemas = append(schemas, *op.Params)
+		}
+	}
+	for _, s := range schemas {
+		for _, f := range s.Fields {
+			if f.Type.Kind == String && f.Type.Pattern != "" {
+				list = append(list, GoPattern{s.PatternVar(f), f.Type.Pattern})
+			}
+		}
+	}
+	return list
+}
+
+// HasParams reports whether any operation takes path or query parameters.
+func (api *API) HasParams() bool {
+	return slices.ContainsFunc(api.Operations, func(op Operation) bool { return op.Params != nil })
+}
+
+// UsesSchemas reports whether handlers outside the schema package refer to
+// it, through parameters or a body.
+func (api *API) UsesSchemas() bool {
+	return slices.ContainsFunc(api.Operations, func(op Operation) bool {
+		return op.Params != nil || op.Body != nil && op.Body.refs()
+	})
+}
+
+func (t Type) refs() bool {
+	for e := &t; e != nil; e = e.elem() {
+		if e.Ref != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// elem is the type of an array's items or a map's values, or nil.
+func (t *Type) elem() *Type {
+	if t.Items != nil {
+		return t.Items
+	}
+	return t.Values
+}
+
+// Python
+
+// pythonKeywords cannot name a function, argument or field.
+var pythonKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue",
+	"def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import",
+	"in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while",
+	"with", "yield",
+}
+
+// PyFunction names the FastAPI route function.
+func (op Operation) PyFunction() string {
+	name := strings.Join(words(op.ID), "_")
+	if slices.Contains(pythonKeywords, name) {
+		name += "_"
+	}
+	return name
+}
+
+// PyName is the field's Python name. Names that are not identifiers are
+// snake-cased and keep the original as their alias.
+func (f Field) PyName() string {
+	// pydantic keeps names with a leading underscore or model_ for itself.
+	if identifierPattern.MatchString(f.Name) && !slices.Contains(pythonKeywords, f.Name) && !strings.HasPrefix(f.Name, "_") && !strings.HasPrefix(f.Name, "model_") {
+		return f.Name
+	}
+	name := strings.Join(words(f.Name), "_")
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "field_" + name
+	}
+	if slices.Contains(pythonKeywords, name) || strings.HasPrefix(name, "model_") {
+		name += "_"
+	}
+	return name
+}
+
+func (f Field) PyType() string {
+	if f.Required {
+		return f.Type.Py()
+	}
+	return f.Type.Py() + " | None"
+}
+
+// PyDefault is what follows the annotation of a pydantic field: nothing,
+// " = None", or a Field call carrying the constraints and alias.
+func (f Field) PyDefault() string {
+	args := f.pyArgs()
+	switch {
+	case len(args) == 0:
+		return ""
+	case len(args) == 1 && !f.Required:
+		return " = None"
+	}
+	return " = Field(" + strings.Join(args, ", ") + ")"
+}
+
+// PyParam is the FastAPI Path or Query declaration of a parameter.
+func (f Field) PyParam() string {
+	if f.In == "path" {
+		return "Path(" + strings.Join(f.pyArgs(), ", ") + ")"
+	}
+	return "Query(" + strings.Join(f.pyArgs(), ", ") + ")"
+}
+
+func (f Field) pyArgs() []string {
+	var args []string
+	if !f.Required {
+		args = append(args, "default=None")
+	}
+	t := f.Type
+	for _, c := range []struct {
+		name string
+		n    *int
+	}{{"min_length", t.MinLength}, {"max_length", t.MaxLength}, {"min_length", t.MinItems}, {"max_length", t.MaxItems}} {
+		if c.n != nil {
+			args = append(args, fmt.Sprintf("%s=%d", c.name, *c.n))
+		}
+	}
+	if t.Pattern != "" {
+		args = append(args, "pattern="+strconv.Quote(t.Pattern))
+	}
+	if t.Minimum != nil {
+		args = append(args, "ge="+formatNumber(*t.Minimum))
+	}
+	if t.Maximum != nil {
+		args = append(args, "le="+formatNumber(*t.Maximum))
+	}
+	if f.PyName() != f.Name {
+		args = append(args, "alias="+strconv.Quote(f.Name))
+	}
+	return args
+}
+
+func (t Type) Py() string {
+	switch t.Kind {
+	case String:
+		if len(t.Enum) > 0 {
+			quoted := make([]string, len(t.Enum))
+			for i, e := range t.Enum {
+				quoted[i] = strconv.Quote(e)
+			}
+			return "Literal[" + strings.Join(quoted, ", ") + "]"
+		}
+		return "str"
+	case Integer:
+		return "int"
+	case Number:
+		return "float"
+	case Boolean:
+		return "bool"
+	case Array:
+		return "list[" + t.Items.Py() + "]"
+	case Object:
+		if t.Ref != "" {
+			return t.Ref
+		}
+		if t.Values != nil {
+			return "dict[str, " + t.Values.Py() + "]"
+		}
+		return "dict[str, Any]"
+	}
+	return "Any"
+}
+
+// PyAliases reports whether any field of s needs an alias.
+func (s Schema) PyAliases() bool {
+	return slices.ContainsFunc(s.Fields, func(f Field) bool { return f.PyName() != f.Name })
+}
+
+// PyImports lists the names a generated Python module imports from
+// typing, from the library it builds on, and from the models module.
+type PyImports struct {
+	Typing  []string
+	Library []string
+	Models  []string
+}
+
+// PyModelImports is what the models module imports; the library is
+// pydantic.
+func (api *API) PyModelImports() PyImports {
+	var typing []string
+	pydantic := []string{"BaseModel"}
+	for _, s := range api.Schemas {
+		if s.PyAliases() && !slices.Contains(pydantic, "ConfigDict") {
+			pydantic = append(pydantic, "ConfigDict")
+		}
+		for _, f := range s.Fields {
+			if strings.HasPrefix(f.PyDefault(), " = Field(") && !slices.Contains(pydantic, "Field") {
+				pydantic = append(pydantic, "Field")
+			}
+			typing = pyTypingOf(typing, f.Type.Py())
+		}
+	}
+	slices.Sort(pydantic)
+	return PyImports{Typing: typing, Library: pydantic}
+}
+
+// PyRouteImports is what the routes module imports; the library is
+// fastapi.
+func (api *API) PyRouteImports() PyImports {
+	var typing, models []string
+	fastapi := []string{"APIRouter", "HTTPException"}
+	add := func(list []string, name string) []string {
+		if !slices.Contains(list, name) {
+			list = append(list, name)
+		}
+		return list
+	}
+	var refs func(t Type)
+	refs = func(t Type) {
+		switch {
+		case t.elem() != nil:
+			refs(*t.elem())
+		case t.Ref != "":
+			models = add(models, t.Ref)
+		}
+	}
+	for _, op := range api.Operations {
+		if op.Params != nil {
+			for _, f := range op.Params.Fields {
+				fastapi = add(fastapi, map[string]string{"path": "Path", "query": "Query"}[f.In])
+				typing = pyTypingOf(typing, f.Type.Py())
+			}
+		}
+		for _, t := range []*Type{op.Body, op.Response} {
+			if t != nil {
+				refs(*t)
+				typing = pyTypingOf(typing, t.Py())
+			}
+		}
+		if op.Body != nil {
+			fastapi = add(fastapi, "Body")
+		}
+	}
+	slices.Sort(fastapi)
+	slices.Sort(models)
+	return PyImports{Typing: typing, Library: fastapi, Models: models}
+}
+
+func pyTypingOf(list []string, annotation string) []string {
+	for _, name := range []string{"Any", "Literal"} {
+		if strings.Contains(annotation, name+"[") || strings.Contains(annotation, name+"]") || annotation == name {
+			if !slices.Contains(list, name) {
+				list = append(list, name)
+				slices.Sort(list)
+			}
+		}
+	}
+	return list
+}
+
+// Describe names the type for comments, e.g. Pet[] for a list of pets.
+func (t Type) Describe() string {
+	switch {
+	case t.Kind == Array:
+		return t.Items.Describe() + "[]"
+	case t.Values != nil:
+		return "map of " + t.Values.Describe()
+	case t.Ref != "":
+		return t.Ref
+	}
+	return string(t.Kind)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+// bound writes a limit for comparing against a value of kind. Integers
+// compare against whole numbers, rounded towards the allowed range.
+func bound(kind Kind, n float64, round func(float64) float64) string {
+	if kind == Integer {
+		n = round(n)
+	}
+	return formatNumber(n)
+}
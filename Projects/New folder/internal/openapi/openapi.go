@@ -0,0 +1,750 @@
+// Package openapi reads an OpenAPI 3 document into the shapes gen-code
+// generates route registration, types and handler stubs from.
+//
+// Only what the generated code needs survives: operations, their path and
+// query parameters, JSON request and response bodies, and object schemas
+// with the usual constraints. Inline objects are hoisted into named
+// schemas, the members of an allOf are merged into one, and references to
+// non-object schemas are inlined. oneOf and anyOf are rejected, since the
+// generated types could not hold them.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// API is the part of a contract gen-code generates code from. It is stored
+// in the project manifest, so gen-code upgrade can regenerate it without
+// the original document.
+type API struct {
+	Title      string      `json:"title"`
+	Version    string      `json:"version"`
+	Operations []Operation `json:"operations"`
+	// Schemas are ordered so that every schema comes after the ones its
+	// fields refer to.
+	Schemas []Schema `json:"schemas,omitempty"`
+}
+
+type Operation struct {
+	// ID is the operationId in camel case, or one made up from the method
+	// and path when the document has none.
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Summary string `json:"summary,omitempty"`
+	// Params holds the path and query parameters as the fields of one
+	// schema, named after the operation.
+	Params *Schema `json:"params,omitempty"`
+	Body   *Type   `json:"body,omitempty"`
+	// Status is the first success status the document lists, and
+	// Response the JSON body sent with it.
+	Status   int   `json:"status"`
+	Response *Type `json:"response,omitempty"`
+}
+
+type Schema struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Fields      []Field `json:"fields"`
+}
+
+type Field struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	// In is "path" or "query" for parameters and empty for object fields.
+	In   string `json:"in,omitempty"`
+	Type Type   `json:"type"`
+}
+
+// Kind is a JSON Schema type.
+type Kind string
+
+const (
+	String  Kind = "string"
+	Integer Kind = "integer"
+	Number  Kind = "number"
+	Boolean Kind = "boolean"
+	Array   Kind = "array"
+	Object  Kind = "object"
+	// Any stands for schemas without a type, which take any JSON value.
+	Any Kind = "any"
+)
+
+// Type is a schema reduced to its kind and constraints. An Object with a
+// Ref is one of the API's schemas; without, it is a map whose values are
+// of type Values, or free-form when Values is nil.
+type Type struct {
+	Kind      Kind     `json:"kind"`
+	Ref       string   `json:"ref,omitempty"`
+	Items     *Type    `json:"items,omitempty"`
+	Values    *Type    `json:"values,omitempty"`
+	Format    string   `json:"format,omitempty"`
+	Enum      []string `json:"enum,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	MinLength *int     `json:"min_length,omitempty"`
+	MaxLength *int     `json:"max_length,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinItems  *int     `json:"min_items,omitempty"`
+	MaxItems  *int     `json:"max_items,omitempty"`
+}
+
+// Load reads an OpenAPI 3 document in YAML or JSON.
+func Load(path string) (*API, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI document: %w", err)
+	}
+	api, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return api, nil
+}
+
+// Parse reads an OpenAPI 3 document. JSON is valid YAML, so both go
+// through the same decoder.
+func Parse(data []byte) (*API, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if doc.Swagger != "" {
+		return nil, fmt.Errorf("swagger %s documents are not supported; convert to OpenAPI 3 first", doc.Swagger)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("not an OpenAPI 3 document (openapi: %q)", doc.OpenAPI)
+	}
+
+	p := &parser{doc: &doc, api: &API{Title: doc.Info.Title, Version: doc.Info.Version}, names: map[string]bool{}}
+	for _, name := range doc.Components.Schemas.keys {
+		p.names[typeName(name)] = true
+	}
+	var errs []error
+	for _, name := range doc.Components.Schemas.keys {
+		merged, err := p.allOf(doc.Components.Schemas.values[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schema %s: %w", name, err))
+			continue
+		}
+		doc.Components.Schemas.values[name] = merged
+	}
+	for _, name := range doc.Components.Schemas.keys {
+		if s := doc.Components.Schemas.values[name]; isObject(s) {
+			if err := p.addSchema(typeName(name), s); err != nil {
+				errs = append(errs, fmt.Errorf("schema %s: %w", name, err))
+			}
+		}
+	}
+
+	base := ""
+	if len(doc.Servers) > 0 {
+		base = basePath(doc.Servers[0].URL)
+	}
+	ids := map[string]string{}
+	for _, path := range doc.Paths.keys {
+		item := doc.Paths.values[path]
+		for _, m := range item.operations() {
+			route := m.method + " " + path
+			op, err := p.operation(base, path, m.method, item.Parameters, m.op)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", route, err))
+				continue
+			}
+			if prev, ok := ids[op.ID]; ok {
+				errs = append(errs, fmt.Errorf("%s: operation id %q is already used by %s", route, op.ID, prev))
+				continue
+			}
+			ids[op.ID] = route
+			p.api.Operations = append(p.api.Operations, op)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if len(p.api.Operations) == 0 {
+		return nil, errors.New("the document has no operations")
+	}
+
+	p.api.Schemas = dependencyOrder(p.api.Schemas)
+	return p.api, nil
+}
+
+// The document as written; only the parts gen-code reads are decoded.
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title   string `yaml:"title"`
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      ordered[pathItem] `yaml:"paths"`
+	Components struct {
+		Schemas       ordered[*schema]        `yaml:"schemas"`
+		Parameters    map[string]*parameter   `yaml:"parameters"`
+		RequestBodies map[string]*requestBody `yaml:"requestBodies"`
+		Responses     map[string]*response    `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Post       *operation   `yaml:"post"`
+	Put        *operation   `yaml:"put"`
+	Patch      *operation   `yaml:"patch"`
+	Delete     *operation   `yaml:"delete"`
+}
+
+type methodOperation struct {
+	method string
+	op     *operation
+}
+
+func (item pathItem) operations() []methodOperation {
+	var ops []methodOperation
+	for _, m := range []methodOperation{{"GET", item.Get}, {"POST", item.Post}, {"PUT", item.Put}, {"PATCH", item.Patch}, {"DELETE", item.Delete}} {
+		if m.op != nil {
+			ops = append(ops, m)
+		}
+	}
+	return ops
+}
+
+type operation struct {
+	OperationID string             `yaml:"operationId"`
+	Summary     string             `yaml:"summary"`
+	Parameters  []*parameter       `yaml:"parameters"`
+	RequestBody *requestBody       `yaml:"requestBody"`
+	Responses   ordered[*response] `yaml:"responses"`
+}
+
+type parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *schema `yaml:"schema"`
+}
+
+type requestBody struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type response struct {
+	Ref     string               `yaml:"$ref"`
+	Content map[string]mediaType `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type schema struct {
+	Ref         string           `yaml:"$ref"`
+	Type        any              `yaml:"type"`
+	Format      string           `yaml:"format"`
+	Description string           `yaml:"description"`
+	Enum        []any            `yaml:"enum"`
+	Pattern     string           `yaml:"pattern"`
+	Items       *schema          `yaml:"items"`
+	Properties  ordered[*schema] `yaml:"properties"`
+	Required    []string         `yaml:"required"`
+	MinLength   *int             `yaml:"minLength"`
+	MaxLength   *int             `yaml:"maxLength"`
+	Minimum     *float64         `yaml:"minimum"`
+	Maximum     *float64         `yaml:"maximum"`
+	MinItems    *int             `yaml:"minItems"`
+	MaxItems    *int             `yaml:"maxItems"`
+	AllOf       []*schema        `yaml:"allOf"`
+	OneOf       []*schema        `yaml:"oneOf"`
+	AnyOf       []*schema        `yaml:"anyOf"`
+
+	AdditionalProperties *additionalProperties `yaml:"additionalProperties"`
+}
+
+// additionalProperties is true, false or the schema of a map's values;
+// only the schema matters to the generated types.
+type additionalProperties struct {
+	schema *schema
+}
+
+func (a *additionalProperties) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return nil
+	}
+	return n.Decode(&a.schema)
+}
+
+// kind returns the schema's type. OpenAPI 3.1 allows a list of types; the
+// first one that is not "null" counts.
+func (s *schema) kind() Kind {
+	switch t := s.Type.(type) {
+	case string:
+		return Kind(t)
+	case []any:
+		for _, v := range t {
+			if v, ok := v.(string); ok && v != "null" {
+				return Kind(v)
+			}
+		}
+	}
+	switch {
+	case len(s.Properties.keys) > 0, s.AdditionalProperties != nil:
+		return Object
+	case s.Items != nil:
+		return Array
+	}
+	return ""
+}
+
+func isObject(s *schema) bool {
+	return s != nil && s.Ref == "" && s.kind() == Object && len(s.Properties.keys) > 0
+}
+
+// ordered is a YAML mapping that remembers the order of its keys, so the
+// generated code follows the document.
+type ordered[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", n.Line)
+	}
+	o.values = map[string]T{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var v T
+		if err := n.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		key := n.Content[i].Value
+		o.keys = append(o.keys, key)
+		o.values[key] = v
+	}
+	return nil
+}
+
+type parser struct {
+	doc *document
+	api *API
+	// names holds every schema name in use, so hoisted inline schemas get
+	// names of their own.
+	names map[string]bool
+	// resolving guards against references that lead back to themselves.
+	resolving []string
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]*)\}`)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (p *parser) operation(base, path, method string, shared []*parameter, raw *operation) (Operation, error) {
+	op := Operation{Method: method, Path: base + path, Summary: strings.TrimSuffix(oneLine(raw.Summary), "."), Status: 200}
+	if raw.OperationID != "" {
+		op.ID = camelName(words(raw.OperationID))
+	} else {
+		op.ID = camelName(words(strings.ToLower(method) + " " + pathParam.ReplaceAllString(path, "$1")))
+	}
+	path = op.Path
+	if op.ID == "" {
+		return op, fmt.Errorf("operation id %q has no letters or digits", raw.OperationID)
+	}
+	name := goName(words(op.ID))
+
+	// Operation parameters override the path's shared ones.
+	var params []*parameter
+	for _, list := range [][]*parameter{shared, raw.Parameters} {
+		for _, param := range list {
+			param, err := p.parameter(param)
+			if err != nil {
+				return op, err
+			}
+			params = slices.DeleteFunc(params, func(q *parameter) bool { return q.Name == param.Name && q.In == param.In })
+			params = append(params, param)
+		}
+	}
+
+	var fields []Field
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !identifierPattern.MatchString(m[1]) {
+			return op, fmt.Errorf("path parameter %q must be a plain identifier", m[1])
+		}
+		i := slices.IndexFunc(params, func(q *parameter) bool { return q.In == "path" && q.Name == m[1] })
+		var s *schema
+		if i >= 0 {
+			s = params[i].Schema
+		}
+		t, err := p.paramType(s)
+		if err != nil {
+			return op, fmt.Errorf("parameter %s: %w", m[1], err)
+		}
+		fields = append(fields, Field{Name: m[1], Required: true, In: "path", Type: t})
+	}
+	// Headers and cookies are left to the handlers.
+	for _, param := range params {
+		if param.In != "query" {
+			continue
+		}
+		t, err := p.paramType(param.Schema)
+		if err != nil {
+			return op, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		fields = append(fields, Field{Name: param.Name, Required: param.Required, In: "query", Type: t})
+	}
+	if len(fields) > 0 {
+		op.Params = &Schema{Name: name + "Params", Fields: fields}
+	}
+
+	if raw.RequestBody != nil {
+		body, err := p.requestBody(raw.RequestBody)
+		if err != nil {
+			return op, err
+		}
+		s, ok := jsonSchema(body.Content)
+		if !ok {
+			return op, errors.New("only JSON request bodies are supported")
+		}
+		t, err := p.typeOf(s, name+"Request")
+		if err != nil {
+			return op, fmt.Errorf("request body: %w", err)
+		}
+		op.Body = &t
+	}
+
+	for _, code := range raw.Responses.keys {
+		status, ok := successStatus(code)
+		if !ok {
+			continue
+		}
+		op.Status = status
+		resp, err := p.response(raw.Responses.values[code])
+		if err != nil {
+			return op, err
+		}
+		if s, ok := jsonSchema(resp.Content); ok {
+			t, err := p.typeOf(s, name+"Response")
+			if err != nil {
+				return op, fmt.Errorf("response %s: %w", code, err)
+			}
+			op.Response = &t
+		}
+		break
+	}
+	return op, nil
+}
+
+// paramType converts a parameter schema; parameters without one are
+// strings.
+func (p *parser) paramType(s *schema) (Type, error) {
+	if s == nil {
+		return Type{Kind: String}, nil
+	}
+	t, err := p.typeOf(s, "")
+	if err != nil {
+		return t, err
+	}
+	switch t.Kind {
+	case String, Integer, Number, Boolean:
+		return t, nil
+	}
+	return t, fmt.Errorf("only string, integer, number and boolean parameters are supported, not %s", t.Kind)
+}
+
+func (p *parser) parameter(param *parameter) (*parameter, error) {
+	if param.Ref == "" {
+		return param, nil
+	}
+	name, err := refName(param.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := p.doc.Components.Parameters[name]
+	if !ok || resolved.Ref != "" {
+		return nil, fmt.Errorf("cannot resolve %s", param.Ref)
+	}
+	return resolved, nil
+}
+
+func (p *parser) requestBody(body *requestBody) (*requestBody, error) {
+	if body.Ref == "" {
+		return body, nil
+	}
+	name, err := refName(body.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := p.doc.Components.RequestBodies[name]
+	if !ok || resolved == nil || resolved.Ref != "" {
+		return nil, fmt.Errorf("cannot resolve %s", body.Ref)
+	}
+	return resolved, nil
+}
+
+func (p *parser) response(resp *response) (*response, error) {
+	if resp == nil || resp.Ref == "" {
+		return resp, nil
+	}
+	name, err := refName(resp.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := p.doc.Components.Responses[name]
+	if !ok || resolved == nil || resolved.Ref != "" {
+		return nil, fmt.Errorf("cannot resolve %s", resp.Ref)
+	}
+	return resolved, nil
+}
+
+// typeOf converts a schema. Inline objects become schemas named after
+// hint.
+func (p *parser) typeOf(s *schema, hint string) (Type, error) {
+	if s == nil {
+		return Type{Kind: Any}, nil
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return Type{}, err
+		}
+		target, ok := p.doc.Components.Schemas.values[name]
+		if !ok {
+			return Type{}, fmt.Errorf("cannot resolve %s", s.Ref)
+		}
+		if isObject(target) {
+			return Type{Kind: Object, Ref: typeName(name)}, nil
+		}
+		if slices.Contains(p.resolving, name) {
+			return Type{}, fmt.Errorf("%s refers to itself", s.Ref)
+		}
+		p.resolving = append(p.resolving, name)
+		defer func() { p.resolving = p.resolving[:len(p.resolving)-1] }()
+		return p.typeOf(target, typeName(name))
+	}
+
+	if len(s.OneOf)+len(s.AnyOf) > 0 {
+		return Type{}, errOneOf
+	}
+	if len(s.AllOf) == 1 && len(s.Properties.keys) == 0 {
+		return p.typeOf(s.AllOf[0], hint)
+	}
+	s, err := p.allOf(s)
+	if err != nil {
+		return Type{}, err
+	}
+
+	t := Type{Kind: s.kind()}
+	switch t.Kind {
+	case String:
+		t.Format, t.Pattern, t.MinLength, t.MaxLength = s.Format, s.Pattern, s.MinLength, s.MaxLength
+		for _, v := range s.Enum {
+			if v, ok := v.(string); ok {
+				t.Enum = append(t.Enum, v)
+			}
+		}
+	case Integer, Number:
+		t.Format, t.Minimum, t.Maximum = s.Format, s.Minimum, s.Maximum
+	case Boolean:
+	case Array:
+		items, err := p.typeOf(s.Items, hint+"Item")
+		if err != nil {
+			return t, err
+		}
+		t.Items, t.MinItems, t.MaxItems = &items, s.MinItems, s.MaxItems
+	case Object:
+		if len(s.Properties.keys) == 0 {
+			if s.AdditionalProperties != nil && s.AdditionalProperties.schema != nil {
+				values, err := p.typeOf(s.AdditionalProperties.schema, hint+"Value")
+				if err != nil {
+					return t, err
+				}
+				t.Values = &values
+			}
+			return t, nil
+		}
+		name := hint
+		for i := 2; p.names[name]; i++ {
+			name = hint + strconv.Itoa(i)
+		}
+		p.names[name] = true
+		if err := p.addSchema(name, s); err != nil {
+			return t, err
+		}
+		t.Ref = name
+	case "":
+		t.Kind = Any
+	default:
+		return t, fmt.Errorf("unsupported type %q", t.Kind)
+	}
+	return t, nil
+}
+
+var errOneOf = errors.New("oneOf and anyOf are not supported; use one schema, or allOf to combine objects")
+
+// allOf merges the members of an allOf, and the schema's own properties,
+// into one object schema. A lone member without properties next to it is
+// left for typeOf to follow, since it may not be an object.
+func (p *parser) allOf(s *schema) (*schema, error) {
+	if s == nil || len(s.AllOf) == 0 || len(s.AllOf) == 1 && len(s.Properties.keys) == 0 {
+		return s, nil
+	}
+	merged := &schema{Type: string(Object), Description: s.Description, Properties: ordered[*schema]{values: map[string]*schema{}}}
+	if err := p.collect(merged, s); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// collect adds the properties and required names of s and its allOf
+// members to into. Later members win over earlier ones.
+func (p *parser) collect(into, s *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return err
+		}
+		target, ok := p.doc.Components.Schemas.values[name]
+		if !ok {
+			return fmt.Errorf("cannot resolve %s", s.Ref)
+		}
+		if slices.Contains(p.resolving, name) {
+			return fmt.Errorf("%s refers to itself", s.Ref)
+		}
+		p.resolving = append(p.resolving, name)
+		defer func() { p.resolving = p.resolving[:len(p.resolving)-1] }()
+		return p.collect(into, target)
+	}
+	if len(s.OneOf)+len(s.AnyOf) > 0 {
+		return errOneOf
+	}
+	if k := s.kind(); k != "" && k != Object {
+		return fmt.Errorf("allOf can only combine objects, not %s", k)
+	}
+	for _, m := range s.AllOf {
+		if err := p.collect(into, m); err != nil {
+			return err
+		}
+	}
+	for _, key := range s.Properties.keys {
+		if _, ok := into.Properties.values[key]; !ok {
+			into.Properties.keys = append(into.Properties.keys, key)
+		}
+		into.Properties.values[key] = s.Properties.values[key]
+	}
+	for _, r := range s.Required {
+		if !slices.Contains(into.Required, r) {
+			into.Required = append(into.Required, r)
+		}
+	}
+	return nil
+}
+
+func (p *parser) addSchema(name string, s *schema) error {
+	schema := Schema{Name: name, Description: oneLine(s.Description)}
+	for _, key := range s.Properties.keys {
+		t, err := p.typeOf(s.Properties.values[key], name+goName(words(key)))
+		if err != nil {
+			return fmt.Errorf("property %s: %w", key, err)
+		}
+		schema.Fields = append(schema.Fields, Field{Name: key, Required: slices.Contains(s.Required, key), Type: t})
+	}
+	p.api.Schemas = append(p.api.Schemas, schema)
+	return nil
+}
+
+// refName returns the name a local reference points to under
+// #/components/<section>/.
+func refName(ref, section string) (string, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/"+section+"/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("unsupported reference %s; only #/components/%s/<name> is followed here", ref, section)
+	}
+	return name, nil
+}
+
+// jsonSchema returns the schema of the JSON entry in an OpenAPI content
+// map.
+func jsonSchema(content map[string]mediaType) (*schema, bool) {
+	if m, ok := content["application/json"]; ok {
+		return m.Schema, true
+	}
+	for key, m := range content {
+		if strings.HasSuffix(key, "+json") {
+			return m.Schema, true
+		}
+	}
+	return nil, false
+}
+
+// oneLine collapses text onto one line for code comments and docstrings.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func successStatus(code string) (int, bool) {
+	if strings.EqualFold(code, "2XX") {
+		return 200, true
+	}
+	n, err := strconv.Atoi(code)
+	return n, err == nil && n >= 200 && n < 300
+}
+
+// basePath returns the path of a server URL, which prefixes every route.
+func basePath(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+		if i := strings.Index(url, "/"); i >= 0 {
+			url = url[i:]
+		} else {
+			url = ""
+		}
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// dependencyOrder sorts schemas so that each follows the schemas it refers
+// to, otherwise keeping their order. Cycles are left as found.
+func dependencyOrder(schemas []Schema) []Schema {
+	byName := map[string]Schema{}
+	for _, s := range schemas {
+		byName[s.Name] = s
+	}
+	var sorted []Schema
+	state := map[string]int{}
+	var visit func(Schema)
+	visit = func(s Schema) {
+		if state[s.Name] != 0 {
+			return
+		}
+		state[s.Name] = 1
+		for _, f := range s.Fields {
+			for t := &f.Type; t != nil; t = t.elem() {
+				if dep, ok := byName[t.Ref]; ok {
+					visit(dep)
+				}
+			}
+		}
+		state[s.Name] = 2
+		sorted = append(sorted, s)
+	}
+	for _, s := range schemas {
+		visit(s)
+	}
+	return sorted
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const petstore = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+servers: [{url: "https://api.example.com/v1"}]
+paths:
+  /pets/{petId}:
+    get:
+      summary: Show one pet.
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+        - {name: X-Trace, in: header, schema: {type: string}}
+      responses:
+        "200":
+          content: {application/json: {schema: {$ref: "#/components/schemas/Pet"}}}
+  /pets:
+    post:
+      operationId: create_pet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, minLength: 1}
+                owner: {$ref: "#/components/schemas/Owner"}
+      responses:
+        "201":
+          content: {application/json: {schema: {$ref: "#/components/schemas/Pet"}}}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer}
+        status: {type: string, enum: [available, sold]}
+        owner: {$ref: "#/components/schemas/Owner"}
+    Owner:
+      type: object
+      properties:
+        class: {type: string}
+`
+
+func TestParse(t *testing.T) {
+	api, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.Operations) != 2 {
+		t.Fatalf("got %d operations, want 2", len(api.Operations))
+	}
+
+	get := api.Operations[0]
+	if get.ID != "getPetsPetId" || get.Method != "GET" || get.Path != "/v1/pets/{petId}" || get.Summary != "Show one pet" {
+		t.Errorf("get: got %s %s %s %q", get.ID, get.Method, get.Path, get.Summary)
+	}
+	if get.GoHandler() != "handleGetPetsPetID" || get.GoPath() != "/v1/pets/:petId" || get.PyFunction() != "get_pets_pet_id" {
+		t.Errorf("get names: got %s %s %s", get.GoHandler(), get.GoPath(), get.PyFunction())
+	}
+	if get.Params == nil || len(get.Params.Fields) != 1 || get.Params.Fields[0].In != "path" {
+		t.Errorf("get params: got %+v, want only the path parameter", get.Params)
+	}
+	if get.Status != 200 || get.Response == nil || get.Response.Ref != "Pet" {
+		t.Errorf("get response: got %d %+v", get.Status, get.Response)
+	}
+
+	post := api.Operations[1]
+	if post.ID != "createPet" || post.Status != 201 || post.Body == nil || post.Body.Ref != "CreatePetRequest" {
+		t.Errorf("post: got %s %d %+v", post.ID, post.Status, post.Body)
+	}
+
+	var names []string
+	for _, s := range api.Schemas {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, " "); got != "Owner Pet CreatePetRequest" {
+		t.Errorf("schemas: got %s, want dependencies first", got)
+	}
+	class := api.Schemas[0].Fields[0]
+	if class.PyName() != "class_" || class.PyDefault() != ` = Field(default=None, alias="class")` {
+		t.Errorf("class field: got %s%s", class.PyName(), class.PyDefault())
+	}
+}
+
+// composed is the usual petstore-expanded shape: Pet extends NewPet with
+// allOf, plus maps typed by additionalProperties.
+const composed = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          content: {application/json: {schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}}}
+components:
+  schemas:
+    Pet:
+      allOf:
+        - $ref: "#/components/schemas/NewPet"
+        - type: object
+          required: [id]
+          properties:
+            id: {type: integer, format: int64}
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        tag: {type: string}
+        labels: {type: object, additionalProperties: {type: string}}
+        friends: {type: object, additionalProperties: {$ref: "#/components/schemas/Owner"}}
+        extra: {type: object, additionalProperties: true}
+    Owner:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestParseComposition(t *testing.T) {
+	api, err := Parse([]byte(composed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := api.Operations[0].Response.Describe(); got != "Pet[]" {
+		t.Errorf("response: got %s, want Pet[]", got)
+	}
+	i := slices.IndexFunc(api.Schemas, func(s Schema) bool { return s.Name == "Pet" })
+	if i < 0 {
+		t.Fatal("no Pet schema")
+	}
+	pet := api.Schemas[i]
+	var fields []string
+	for _, f := range pet.Fields {
+		fields = append(fields, fmt.Sprintf("%s:%s:%t", f.Name, f.GoType(), f.Required))
+	}
+	want := "name:string:true tag:string:false labels:map[string]string:false friends:map[string]Owner:false extra:map[string]any:false id:int64:true"
+	if got := strings.Join(fields, " "); got != want {
+		t.Errorf("Pet fields:\n got %s\nwant %s", got, want)
+	}
+	if py := pet.Fields[3].Type.Py(); py != "dict[str, Owner]" {
+		t.Errorf("friends in Python: %s", py)
+	}
+	if checks := pet.GoChecks(pet.Fields[3]); len(checks) != 1 || !checks[0].Keyed {
+		t.Errorf("friends checks: %+v, want each value validated", checks)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`swagger: "2.0"`, "convert to OpenAPI 3"},
+		{`openapi: 3.0.0
+paths:
+  /a: {get: {operationId: same, responses: {}}}
+  /b: {get: {operationId: same, responses: {}}}`, "already used"},
+		{`openapi: 3.0.0
+paths:
+  /a:
+    post:
+      requestBody: {content: {text/plain: {schema: {type: string}}}}
+      responses: {}`, "JSON"},
+		{`openapi: 3.0.0
+paths: {}`, "no operations"},
+		{`openapi: 3.0.0
+paths:
+  /a:
+    get:
+      responses:
+        "200": {content: {application/json: {schema: {oneOf: [{type: string}, {type: integer}]}}}}`, "oneOf and anyOf are not supported"},
+		{`openapi: 3.0.0
+paths:
+  /a:
+    post:
+      requestBody: {content: {application/json: {schema: {type: object, properties: {v: {anyOf: [{type: string}]}}}}}}
+      responses: {}`, "oneOf and anyOf are not supported"},
+		{`openapi: 3.0.0
+paths:
+  /a:
+    post:
+      requestBody: {content: {application/json: {schema: {allOf: [{type: string}, {type: object, properties: {a: {type: string}}}]}}}}
+      responses: {}`, "allOf can only combine objects"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got %v, want %q", err, tt.want)
+		}
+	}
+}
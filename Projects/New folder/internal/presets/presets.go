@@ -0,0 +1,218 @@
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gen-code/internal/answers"
+	"gen-code/internal/matrix"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRecent is how many generated projects the history keeps.
+const maxRecent = 10
+
+// Preset is a named stack: everything the wizard asks except the app name
+// and the output path, which differ for every project.
+type Preset struct {
+	Name        string             `yaml:"name"`
+	Language    matrix.Language    `yaml:"language"`
+	Framework   matrix.Framework   `yaml:"framework"`
+	ProjectType matrix.ProjectType `yaml:"project_type"`
+	Complexity  matrix.Complexity  `yaml:"complexity"`
+	Port        int                `yaml:"port,omitempty"`
+	Addons      []matrix.Addon     `yaml:"addons,omitempty"`
+}
+
+// Recent is a project gen-code generated, kept so it can be regenerated or
+// used as the starting point for the next one.
+type Recent struct {
+	Answers     answers.Answers `yaml:",inline"`
+	GeneratedAt time.Time       `yaml:"generated_at"`
+}
+
+// Store is the user's config file.
+type Store struct {
+	Presets []Preset `yaml:"presets,omitempty"`
+	Recent  []Recent `yaml:"recent,omitempty"`
+
+	path string
+	// broken is why the file could not be loaded. Save refuses to replace
+	// it, so a typo never costs the user their presets.
+	broken error
+}
+
+// DefaultPath is where presets and history live when no path is given.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gen-code", "config.yaml")
+}
+
+// Load reads the config file at path. A missing file gives an empty store
+// that Save will create; an unreadable one gives an empty store that Save
+// leaves alone.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		s.broken = fmt.Errorf("failed to read config: %w", err)
+		return s, s.broken
+	}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		s = &Store{path: path, broken: fmt.Errorf("failed to parse config %s: %w", path, err)}
+		return s, s.broken
+	}
+	return s, nil
+}
+
+// Save writes the store back to the file it was loaded from. The file is
+// replaced in one rename so a crash never leaves it half written.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return errors.New("no config file location (set one with -config)")
+	}
+	if s.broken != nil {
+		return fmt.Errorf("%w; fix or remove it first, it was left untouched", s.broken)
+	}
+
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Path() string { return s.path }
+
+// Preset looks a preset up by name, ignoring case.
+func (s *Store) Preset(name string) (Preset, bool) {
+	for _, p := range s.Presets {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// SavePreset adds p, replacing any preset with the same name.
+func (s *Store) SavePreset(p Preset) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("preset name is required")
+	}
+	i := slices.IndexFunc(s.Presets, func(q Preset) bool { return strings.EqualFold(q.Name, p.Name) })
+	if i >= 0 {
+		s.Presets[i] = p
+	} else {
+		s.Presets = append(s.Presets, p)
+	}
+	return nil
+}
+
+// Remember puts a generated project at the top of the history. Generating
+// into the same output again moves it up instead of adding a duplicate.
+func (s *Store) Remember(a answers.Answers, at time.Time) {
+	if abs, err := filepath.Abs(a.Output); err == nil {
+		a.Output = abs
+	}
+	s.Recent = slices.DeleteFunc(s.Recent, func(r Recent) bool { return r.Answers.Output == a.Output })
+	s.Recent = append([]Recent{{Answers: a, GeneratedAt: at}}, s.Recent...)
+	if len(s.Recent) > maxRecent {
+		s.Recent = s.Recent[:maxRecent]
+	}
+}
+
+// RecentProject returns the n-th most recent project, counting from 1.
+func (s *Store) RecentProject(n int) (Recent, error) {
+	if n < 1 || n > len(s.Recent) {
+		return Recent{}, fmt.Errorf("no recent project #%d (history has %d)", n, len(s.Recent))
+	}
+	return s.Recent[n-1], nil
+}
+
+// FromAnswers captures the stack of a, dropping the per-project fields.
+func FromAnswers(name string, a answers.Answers) Preset {
+	return Preset{
+		Name:        name,
+		Language:    a.Language,
+		Framework:   a.Framework,
+		ProjectType: a.ProjectType,
+		Complexity:  a.Complexity,
+		Port:        a.Port,
+		Addons:      slices.Clone(a.Addons),
+	}
+}
+
+// Apply fills the stack fields of a from the preset, keeping its app name,
+// output and module path.
+func (p Preset) Apply(a answers.Answers) answers.Answers {
+	a.Language = p.Language
+	a.Framework = p.Framework
+	a.ProjectType = p.ProjectType
+	a.Complexity = p.Complexity
+	a.Port = p.Port
+	a.Addons = slices.Clone(p.Addons)
+	return a
+}
+
+// Summary describes the stack in one line for menus and listings.
+func (p Preset) Summary() string {
+	s := fmt.Sprintf("%s/%s %s, %s", p.Language, p.Framework, p.ProjectType, p.Complexity)
+	if len(p.Addons) > 0 {
+		names := make([]string, len(p.Addons))
+		for i, a := range p.Addons {
+			names[i] = string(a)
+		}
+		s += " + " + strings.Join(names, ", ")
+	}
+	return s
+}
+
+// Validate checks the preset against the matrix and returns it with
+// canonical values, so a preset written by hand with short forms such as
+// "go" or "minimal" works.
+func (p Preset) Validate() (Preset, error) {
+	// The stack checks live on Answers; the placeholder name passes every
+	// language's naming rules.
+	a, err := p.Apply(answers.Answers{AppName: "preset", Output: "."}).Validate()
+	if err != nil {
+		return p, fmt.Errorf("preset %s: %w", p.Name, err)
+	}
+	return FromAnswers(p.Name, a), nil
+}
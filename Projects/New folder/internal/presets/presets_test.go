@@ -0,0 +1,93 @@
+package presets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gen-code/internal/answers"
+	"gen-code/internal/matrix"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gen-code", "config.yaml")
+	s, err := Load(path)
+	if err != nil || len(s.Presets) != 0 {
+		t.Fatalf("missing file: got %+v, %v", s, err)
+	}
+
+	a := answers.Answers{AppName: "shop", Language: matrix.Go, Framework: matrix.Gin, ProjectType: matrix.Backend, Complexity: matrix.Minimal, Output: "shop", Addons: []matrix.Addon{matrix.SQLite}}
+	if err := s.SavePreset(FromAnswers("team-go-api", a)); err != nil {
+		t.Fatal(err)
+	}
+	a.Complexity = matrix.Standard
+	if err := s.SavePreset(FromAnswers("Team-Go-API", a)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SavePreset(Preset{Name: " "}); err == nil {
+		t.Error("saved a preset without a name")
+	}
+	for i := range maxRecent + 2 {
+		s.Remember(answers.Answers{AppName: fmt.Sprint(i), Output: fmt.Sprint(i)}, time.Now())
+	}
+	s.Remember(answers.Answers{AppName: "again", Output: "5"}, time.Now())
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Presets) != 1 || got.Presets[0].Complexity != matrix.Standard {
+		t.Errorf("presets = %+v, want one replaced preset", got.Presets)
+	}
+	if p, ok := got.Preset("TEAM-go-api"); !ok || len(p.Addons) != 1 {
+		t.Errorf("lookup: got %+v, %v", p, ok)
+	}
+	if len(got.Recent) != maxRecent {
+		t.Fatalf("history has %d entries, want %d", len(got.Recent), maxRecent)
+	}
+	if r, _ := got.RecentProject(1); r.Answers.AppName != "again" || !filepath.IsAbs(r.Answers.Output) {
+		t.Errorf("most recent = %+v", r.Answers)
+	}
+	if _, err := got.RecentProject(maxRecent + 1); err == nil {
+		t.Error("RecentProject accepted an index past the history")
+	}
+}
+
+func TestPresetValidate(t *testing.T) {
+	p, err := Preset{Name: "py", Language: "python", Framework: "fastapi", ProjectType: "backend", Complexity: "minimal"}.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Language != matrix.Python || p.Framework != matrix.FastAPI || p.Complexity != matrix.Minimal {
+		t.Errorf("not canonicalised: %+v", p)
+	}
+
+	if _, err := (Preset{Name: "bad", Language: "go", Framework: "django"}).Validate(); err == nil {
+		t.Error("accepted a framework of another language")
+	}
+}
+
+func TestSaveKeepsUnreadableConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	bad := []byte("presets: [\n")
+	if err := os.WriteFile(path, bad, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err == nil {
+		t.Fatal("loaded a config that does not parse")
+	}
+	s.Remember(answers.Answers{AppName: "shop", Output: "shop"}, time.Now())
+	if err := s.Save(); err == nil || !strings.Contains(err.Error(), "left untouched") {
+		t.Errorf("Save = %v, want a refusal", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(bad) {
+		t.Errorf("config was replaced with %q", data)
+	}
+}
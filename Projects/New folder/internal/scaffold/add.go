@@ -0,0 +1,179 @@
+package scaffold
+
+import (
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gen-code/internal/matrix"
+)
+
+// Add puts a generated piece, e.g. a resource, into the project in
+// opts.OutputDir. The project's manifest says how it was generated, so the
+// new files follow its layout. Files the piece needs are created, and the
+// ones that register it, like the route table, are edited in place. Adding
+// the same piece twice is an error. opts.Policy is not used.
+func Add(opts Options, kind matrix.GeneratorKind, name string) (*Report, error) {
+	manifest, err := LoadManifest(opts.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	spec := manifest.Spec
+	addition, err := matrix.GetAddition(spec, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{OutputDir: opts.OutputDir, DryRun: opts.DryRun}
+	contents := map[string]string{}
+	modes := map[string]fs.FileMode{}
+
+	for _, file := range addition.Files {
+		content := withHeader(file, spec)
+		modes[file.Path] = file.Mode
+		entry := Entry{Path: file.Path, Action: ActionCreate, WrittenTo: file.Path}
+
+		existing, err := os.ReadFile(filepath.Join(opts.OutputDir, file.Path))
+		switch {
+		case err != nil && missing(err):
+		case err != nil:
+			return report, fmt.Errorf("failed to read existing %s: %w", file.Path, err)
+		case string(existing) == content:
+			entry.Action, entry.WrittenTo = ActionUnchanged, ""
+		default:
+			return report, fmt.Errorf("%s %q would replace %s, which already exists", kind, name, file.Path)
+		}
+
+		report.Entries = append(report.Entries, entry)
+		if entry.WrittenTo != "" {
+			contents[file.Path] = content
+		}
+	}
+
+	// Several insertions may edit one file; apply them in order and report
+	// the file once.
+	originals, edited := map[string]string{}, map[string]string{}
+	var order []string
+	for _, ins := range addition.Insertions {
+		current, ok := edited[ins.Path]
+		if !ok {
+			data, err := os.ReadFile(filepath.Join(opts.OutputDir, ins.Path))
+			if err != nil {
+				return report, fmt.Errorf("cannot add a %s to this project: %w", kind, err)
+			}
+			current = string(data)
+			originals[ins.Path] = current
+			order = append(order, ins.Path)
+		}
+		if ins.Unique != "" && regexp.MustCompile("(?m)"+ins.Unique).MatchString(originals[ins.Path]) {
+			return report, fmt.Errorf("%s already has a %s named %q", ins.Path, kind, name)
+		}
+		updated, err := insert(current, ins)
+		if err != nil {
+			return report, err
+		}
+		edited[ins.Path] = updated
+	}
+
+	for _, p := range order {
+		original, content := originals[p], edited[p]
+		if strings.HasSuffix(p, ".go") {
+			formatted, err := format.Source([]byte(content))
+			if err != nil {
+				return report, fmt.Errorf("editing %s produced invalid Go: %w", p, err)
+			}
+			content = string(formatted)
+		}
+
+		entry := Entry{Path: p, Action: ActionUpdate, WrittenTo: p, Diff: unifiedDiff(p, original, content)}
+		if content == original {
+			entry = Entry{Path: p, Action: ActionUnchanged}
+		}
+		report.Entries = append(report.Entries, entry)
+		if entry.WrittenTo != "" {
+			contents[p] = content
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	return report, write(Dir(opts.OutputDir), report, contents, modes, nil)
+}
+
+// insert applies one insertion to content; see matrix.Insertion for where
+// the text goes.
+func insert(content string, ins matrix.Insertion) (string, error) {
+	text := strings.TrimSuffix(ins.Text, "\n")
+	if strings.Contains(content, text) {
+		return content, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	find := func(pattern string, from int) int {
+		re := regexp.MustCompile(pattern)
+		for i := from; i < len(lines); i++ {
+			if re.MatchString(lines[i]) {
+				return i
+			}
+		}
+		return -1
+	}
+	anchorErr := func(pattern string) error {
+		return fmt.Errorf("%s has no line matching %s; it may have been edited since it was generated", ins.Path, pattern)
+	}
+
+	at := len(lines)
+	switch {
+	case ins.Replace != "":
+		i := find(ins.Replace, 0)
+		if i < 0 {
+			return "", anchorErr(ins.Replace)
+		}
+		lines = slices.Delete(lines, i, i+1)
+		at = i
+	case ins.Sorted != "":
+		last := -1
+		for i := find(ins.Sorted, 0); i >= 0; i = find(ins.Sorted, i+1) {
+			if lines[i] > text {
+				at = i
+				break
+			}
+			last = i
+		}
+		switch {
+		case at < len(lines):
+		case last >= 0:
+			at = last + 1
+		default:
+			return "", anchorErr(ins.Sorted)
+		}
+	case ins.After != "" || ins.Before != "":
+		start := 0
+		if ins.After != "" {
+			i := find(ins.After, 0)
+			if i < 0 {
+				return "", anchorErr(ins.After)
+			}
+			start, at = i+1, i+1
+		}
+		if ins.Before != "" {
+			i := find(ins.Before, start)
+			if i < 0 {
+				return "", anchorErr(ins.Before)
+			}
+			for i > start && lines[i-1] == "" {
+				i--
+			}
+			at = i
+		}
+	}
+
+	lines = slices.Insert(lines, at, strings.Split(text, "\n")...)
+	return strings.Join(lines, "\n") + "\n", nil
+}
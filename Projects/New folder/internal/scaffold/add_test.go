@@ -0,0 +1,77 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestInsert(t *testing.T) {
+	const file = "import a\nimport c\n\nfunc routes() {\n\tone()\n\n}\n"
+	tests := []struct {
+		ins  matrix.Insertion
+		want string
+	}{
+		{matrix.Insertion{Sorted: `^import `, Text: "import b"}, "import a\nimport b\nimport c\n\nfunc routes() {\n\tone()\n\n}\n"},
+		{matrix.Insertion{Sorted: `^import `, Text: "import d"}, "import a\nimport c\nimport d\n\nfunc routes() {\n\tone()\n\n}\n"},
+		{matrix.Insertion{After: `^func routes`, Before: `^\}$`, Text: "\ttwo()"}, "import a\nimport c\n\nfunc routes() {\n\tone()\n\ttwo()\n\n}\n"},
+		{matrix.Insertion{After: `^func routes`, Text: "\tzero()"}, "import a\nimport c\n\nfunc routes() {\n\tzero()\n\tone()\n\n}\n"},
+		{matrix.Insertion{Replace: `^import c$`, Text: "import c2"}, "import a\nimport c2\n\nfunc routes() {\n\tone()\n\n}\n"},
+		{matrix.Insertion{Text: "// end"}, file + "// end\n"},
+		{matrix.Insertion{Text: "\tone()"}, file},
+	}
+	for _, tt := range tests {
+		got, err := insert(file, tt.ins)
+		if err != nil || got != tt.want {
+			t.Errorf("insert(%+v) = %q, %v; want %q", tt.ins, got, err, tt.want)
+		}
+	}
+
+	if _, err := insert(file, matrix.Insertion{Path: "routes.go", After: `^func missing`, Text: "x"}); err == nil {
+		t.Error("inserted after a line the file does not have")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Scaffold(testSpec, Options{OutputDir: dir}); err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+
+	report, err := Add(Options{OutputDir: dir, DryRun: true}, matrix.GenResource, "orders")
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "internal/server/orders.go")); err == nil {
+		t.Fatal("dry run wrote files")
+	}
+	if report.Count(ActionCreate) != 2 || report.Count(ActionUpdate) != 1 {
+		t.Fatalf("expected a handler, its test and a route update, got %s", report.Summary())
+	}
+
+	if _, err := Add(Options{OutputDir: dir}, matrix.GenResource, "orders"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if _, err := Add(Options{OutputDir: dir}, matrix.GenMiddleware, "audit"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	routes, err := os.ReadFile(filepath.Join(dir, "internal/server/routes.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\ts.router.Use(s.auditMiddleware())\n\ts.router.GET(\"/health\"", "\ts.registerOrders(s.router.Group(\"/api/v1\"))\n}"} {
+		if !strings.Contains(string(routes), want) {
+			t.Errorf("routes.go lacks %q:\n%s", want, routes)
+		}
+	}
+
+	if _, err := Add(Options{OutputDir: dir}, matrix.GenResource, "orders"); err == nil {
+		t.Error("added the same resource twice")
+	}
+	if _, err := Add(Options{OutputDir: dir}, matrix.GenCommand, "sync"); err == nil {
+		t.Error("added a command to a backend service")
+	}
+}
@@ -0,0 +1,215 @@
+package scaffold
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// lineDiff returns the edit script turning a into b, computed from the
+// longest common subsequence of their lines. Generated files are small, so
+// the quadratic table is not a concern.
+func lineDiff(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// unifiedDiff renders the difference between old and new in unified diff
+// format. It returns an empty string when they are equal.
+func unifiedDiff(name, old, new string) string {
+	if binary(old) || binary(new) {
+		if old == new {
+			return ""
+		}
+		return fmt.Sprintf("Binary files a/%s and b/%s differ\n", name, name)
+	}
+	a, b := splitLines(old), splitLines(new)
+	ops := lineDiff(a, b)
+
+	var changed []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+
+	// Group changes whose context windows touch into one hunk.
+	for k := 0; k < len(changed); {
+		start := max(changed[k]-diffContext, 0)
+		end := changed[k]
+		for k < len(changed) && changed[k] <= end+2*diffContext {
+			end = changed[k]
+			k++
+		}
+		end = min(end+diffContext, len(ops)-1)
+
+		// Line numbers are 1-based positions of the hunk in each file.
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start : end+1] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, op := range ops[start : end+1] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+	}
+
+	return sb.String()
+}
+
+// Conflict markers written by merge3 around lines both sides changed.
+const (
+	markerLocal     = "<<<<<<< local"
+	markerSeparator = "======="
+	markerGenerated = ">>>>>>> generated"
+)
+
+// matches maps every line of a to the line of b it is kept as, or -1 when
+// the line was deleted.
+func matches(a, b []string) []int {
+	m := make([]int, len(a))
+	i, j := 0, 0
+	for _, op := range lineDiff(a, b) {
+		switch op.kind {
+		case ' ':
+			m[i] = j
+			i++
+			j++
+		case '-':
+			m[i] = -1
+			i++
+		case '+':
+			j++
+		}
+	}
+	return m
+}
+
+// merge3 applies the changes from base to generated on top of local. Base
+// lines kept by both sides split the files into chunks; a chunk changed on
+// only one side takes that side, one changed differently on both sides is
+// written with conflict markers. It returns the merged text and the number
+// of conflicts.
+func merge3(base, local, generated string) (string, int) {
+	b, l, g := splitLines(base), splitLines(local), splitLines(generated)
+	ml, mg := matches(b, l), matches(b, g)
+
+	var out []string
+	conflicts := 0
+	i, jl, jg := 0, 0, 0
+	for {
+		k := i
+		for k < len(b) && (ml[k] < 0 || mg[k] < 0) {
+			k++
+		}
+		endL, endG := len(l), len(g)
+		if k < len(b) {
+			endL, endG = ml[k], mg[k]
+		}
+
+		cb, cl, cg := b[i:k], l[jl:endL], g[jg:endG]
+		switch {
+		case slices.Equal(cl, cg), slices.Equal(cb, cg):
+			out = append(out, cl...)
+		case slices.Equal(cb, cl):
+			out = append(out, cg...)
+		default:
+			conflicts++
+			out = append(out, markerLocal)
+			out = append(out, cl...)
+			out = append(out, markerSeparator)
+			out = append(out, cg...)
+			out = append(out, markerGenerated)
+		}
+
+		if k == len(b) {
+			break
+		}
+		out = append(out, b[k])
+		i, jl, jg = k+1, ml[k]+1, mg[k]+1
+	}
+
+	if len(out) == 0 {
+		return "", conflicts
+	}
+	return strings.Join(out, "\n") + "\n", conflicts
+}
+
+// binary reports whether content is not text, so that it can be neither
+// diffed nor merged line by line.
+func binary(content string) bool {
+	return !utf8.ValidString(content) || strings.IndexByte(content, 0) >= 0
+}
@@ -1,38 +1,208 @@
 package scaffold
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
 
 	"gen-code/internal/matrix"
 )
 
-const signatureHeader = `// Code generated by Gen Code; DO NOT EDIT.
-// Built with passion and code mastery.
-`
+// Policy decides what happens to a generated file whose path already holds
+// a file with different content.
+type Policy string
 
-func Scaffold(appName string, lang matrix.Language, fw matrix.Framework, pt matrix.ProjectType, c matrix.Complexity, outputDir string) error {
-	m := matrix.GetMatrix(lang, fw, pt, c)
+const (
+	PolicyAbort     Policy = "abort"
+	PolicySkip      Policy = "skip"
+	PolicyOverwrite Policy = "overwrite"
+	PolicyNewCopy   Policy = "new"
+)
+
+var Policies = []Policy{PolicyAbort, PolicySkip, PolicyOverwrite, PolicyNewCopy}
+
+func (p Policy) Description() string {
+	switch p {
+	case PolicyAbort:
+		return "Stop without writing anything"
+	case PolicySkip:
+		return "Keep existing files, write only new ones"
+	case PolicyOverwrite:
+		return "Replace existing files with the generated version"
+	case PolicyNewCopy:
+		return "Keep existing files, write generated ones next to them as .new"
+	}
+	return ""
+}
+
+func ParsePolicy(s string) (Policy, error) {
+	for _, p := range Policies {
+		if strings.EqualFold(strings.TrimSpace(s), string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (valid: abort, skip, overwrite, new)", s)
+}
+
+type Options struct {
+	OutputDir string
+	// Sink receives the project from Scaffold; nil writes it to OutputDir.
+	// Upgrade and Add always work on OutputDir.
+	Sink Sink
+	// Policy defaults to PolicyAbort.
+	Policy Policy
+	// DryRun plans everything, including diffs, but writes nothing.
+	DryRun bool
+	// Exclude names generated files to leave out, such as the ones
+	// unticked in the wizard's file review. They are still recorded in the
+	// manifest, so gen-code upgrade does not bring them back.
+	Exclude []string
+}
+
+// ConflictError is returned under PolicyAbort when generated files would
+// replace existing files with different content.
+type ConflictError struct {
+	Paths []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%d file(s) already exist with different content: %s", len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+func Scaffold(spec matrix.Spec, opts Options) (*Report, error) {
+	files, err := generate(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Policy == "" {
+		opts.Policy = PolicyAbort
+	}
+
+	sink := opts.Sink
+	if sink == nil {
+		sink = Dir(opts.OutputDir)
+	}
 
-	signature := "// Code generated by Gen Code; DO NOT EDIT.\n// Created by: Moeed ul Hassan\n// Project: " + appName + "\n\n"
+	report := &Report{OutputDir: opts.OutputDir, DryRun: opts.DryRun}
+	contents := map[string]string{}
+	generated := map[string]string{}
+	modes := map[string]fs.FileMode{}
+	var conflicts []string
 
-	for _, file := range m.Files {
-		fullPath := filepath.Join(outputDir, file.Path)
+	// Decide the fate of every file before touching the sink, so that an
+	// abort leaves the output exactly as it was.
+	for _, file := range files {
+		content := withHeader(file, spec)
+		generated[file.Path] = content
+		modes[file.Path] = file.Mode
+		if slices.Contains(opts.Exclude, file.Path) {
+			report.Entries = append(report.Entries, Entry{Path: file.Path, Action: ActionExcluded})
+			continue
+		}
+		entry := Entry{Path: file.Path, Action: ActionCreate, WrittenTo: file.Path}
 
-		// Create directory if it doesn't exist
-		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", file.Path, err)
+		existing, err := sink.ReadFile(file.Path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// Nothing there yet.
+		case err != nil:
+			return report, fmt.Errorf("failed to read existing %s: %w", file.Path, err)
+		case string(existing) == content:
+			entry.Action, entry.WrittenTo = ActionUnchanged, ""
+		default:
+			entry.Diff = unifiedDiff(file.Path, string(existing), content)
+			switch opts.Policy {
+			case PolicyAbort:
+				entry.Action, entry.WrittenTo = ActionConflict, ""
+				conflicts = append(conflicts, file.Path)
+			case PolicySkip:
+				entry.Action, entry.WrittenTo = ActionSkip, ""
+			case PolicyOverwrite:
+				entry.Action = ActionOverwrite
+			case PolicyNewCopy:
+				entry.Action, entry.WrittenTo = ActionNewCopy, file.Path+".new"
+			}
 		}
 
-		// Prepare content with signature header
-		content := signature + file.Content
+		report.Entries = append(report.Entries, entry)
+		if entry.WrittenTo != "" {
+			contents[entry.WrittenTo] = content
+		}
+	}
+
+	// A dry run exists to show conflicts, so it reports them instead of failing.
+	if opts.DryRun {
+		return report, nil
+	}
+	if len(conflicts) > 0 {
+		return report, &ConflictError{Paths: conflicts}
+	}
+
+	extra, err := newManifest(spec, generated).files(generated)
+	if err != nil {
+		return report, err
+	}
+	return report, write(sink, report, contents, modes, extra)
+}
+
+// Preview renders the project as Scaffold would write it, without looking
+// at the output, so the files can be reviewed before anything is written.
+func Preview(spec matrix.Spec) ([]File, error) {
+	files, err := generate(spec)
+	if err != nil {
+		return nil, err
+	}
+	preview := make([]File, len(files))
+	for i, file := range files {
+		preview[i] = File{Path: file.Path, Data: []byte(withHeader(file, spec)), Mode: file.Mode}
+	}
+	return preview, nil
+}
 
-		// Write file
-		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", file.Path, err)
+// generate renders the project and merges in its add-ons. Directory
+// markers become empty .gitkeep files, since neither git nor the archive
+// formats keep an empty directory otherwise. Links become files holding
+// their target, which the sinks write as symbolic links.
+func generate(spec matrix.Spec) ([]matrix.FileTemplate, error) {
+	m, err := matrix.GetMatrix(spec)
+	if err != nil {
+		return nil, err
+	}
+	files, err := merge(m)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		switch {
+		case f.Dir:
+			files[i] = matrix.FileTemplate{Path: path.Join(f.Path, ".gitkeep"), NoHeader: true}
+		case f.Link != "":
+			if !matrix.LinkInside(f.Path, f.Link) {
+				return nil, fmt.Errorf("link %s points to %q, outside the project", f.Path, f.Link)
+			}
+			files[i] = matrix.FileTemplate{Path: f.Path, Content: f.Link, Mode: fs.ModeSymlink, NoHeader: true}
 		}
 	}
+	return files, nil
+}
 
-	return nil
+// write hands the files the report says to write to sink in one go,
+// together with extra files such as the manifest. modes is keyed by the
+// generated path, so a .new copy keeps the mode of its original.
+func write(sink Sink, report *Report, contents map[string]string, modes map[string]fs.FileMode, extra map[string]string) error {
+	var files []File
+	for _, entry := range report.Entries {
+		if entry.WrittenTo != "" {
+			files = append(files, File{Path: entry.WrittenTo, Data: []byte(contents[entry.WrittenTo]), Mode: modes[entry.Path]})
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(extra)) {
+		files = append(files, File{Path: p, Data: []byte(extra[p])})
+	}
+	return sink.Write(files)
 }
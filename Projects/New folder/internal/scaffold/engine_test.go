@@ -0,0 +1,199 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+var testSpec = matrix.Spec{
+	AppName:     "demo",
+	Language:    matrix.Go,
+	Framework:   matrix.Gin,
+	ProjectType: matrix.Backend,
+	Complexity:  matrix.Minimal,
+}
+
+func TestScaffoldCreatesFiles(t *testing.T) {
+	dir := t.TempDir()
+	report, err := Scaffold(testSpec, Options{OutputDir: dir})
+	if err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+	if report.Count(ActionCreate) != len(report.Entries) {
+		t.Fatalf("expected every file to be created, got %s", report.Summary())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+		t.Fatalf("main.go not written: %v", err)
+	}
+}
+
+func TestScaffoldExclude(t *testing.T) {
+	preview, err := Preview(testSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	report, err := Scaffold(testSpec, Options{OutputDir: dir, Exclude: []string{"main.go"}})
+	if err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+	if len(report.Entries) != len(preview) || report.Count(ActionExcluded) != 1 {
+		t.Fatalf("got %s for %d previewed files", report.Summary(), len(preview))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); !os.IsNotExist(err) {
+		t.Fatalf("excluded main.go was written")
+	}
+
+	// The manifest tracks it, so an upgrade leaves it out too.
+	report, err = Upgrade(Options{OutputDir: dir, DryRun: true})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if report.Count(ActionSkip) != 1 || report.Count(ActionCreate) != 0 {
+		t.Fatalf("upgrade would bring main.go back: %s", report.Summary())
+	}
+}
+
+func TestScaffoldModesAssetsAndDirs(t *testing.T) {
+	spec := matrix.Spec{AppName: "demo", Language: matrix.Python, Framework: matrix.Django, ProjectType: matrix.WebApp, Complexity: matrix.Enterprise}
+	dir := t.TempDir()
+	if _, err := Scaffold(spec, Options{OutputDir: dir}); err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "entrypoint.sh"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("entrypoint.sh is not executable: %v, %v", info, err)
+	}
+	icon, err := os.ReadFile(filepath.Join(dir, "static", "favicon.ico"))
+	if err != nil || !bytes.HasPrefix(icon, []byte{0, 0, 1, 0}) {
+		t.Errorf("favicon.ico is not the icon as is: %v", err)
+	}
+	if keep, err := os.ReadFile(filepath.Join(dir, "static", "img", ".gitkeep")); err != nil || len(keep) != 0 {
+		t.Errorf("static/img/.gitkeep = %q, %v", keep, err)
+	}
+
+	// Nobody edited the icon, so an upgrade leaves it alone even though the
+	// snapshots cannot hold it.
+	report, err := Upgrade(Options{OutputDir: dir, DryRun: true})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if report.Count(ActionUnchanged) != len(report.Entries) {
+		t.Errorf("upgrade of a fresh project: %s", report.Summary())
+	}
+}
+
+func TestScaffoldPolicies(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Scaffold(testSpec, Options{OutputDir: dir})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || len(conflict.Paths) != 1 || conflict.Paths[0] != "main.go" {
+		t.Fatalf("expected a conflict on main.go, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); !os.IsNotExist(err) {
+		t.Fatalf("abort must not write anything, go.mod exists")
+	}
+
+	report, err := Scaffold(testSpec, Options{OutputDir: dir, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if !strings.Contains(report.Diffs(), "-package old") {
+		t.Fatalf("dry run diff missing the existing content:\n%s", report.Diffs())
+	}
+
+	if _, err := Scaffold(testSpec, Options{OutputDir: dir, Policy: PolicySkip}); err != nil {
+		t.Fatalf("skip failed: %v", err)
+	}
+	if data, _ := os.ReadFile(mainPath); string(data) != "package old\n" {
+		t.Fatalf("skip overwrote main.go")
+	}
+
+	if _, err := Scaffold(testSpec, Options{OutputDir: dir, Policy: PolicyNewCopy}); err != nil {
+		t.Fatalf("new copy failed: %v", err)
+	}
+	if _, err := os.Stat(mainPath + ".new"); err != nil {
+		t.Fatalf("main.go.new not written: %v", err)
+	}
+
+	report, err = Scaffold(testSpec, Options{OutputDir: dir, Policy: PolicyOverwrite})
+	if err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if report.Count(ActionOverwrite) != 1 {
+		t.Fatalf("expected one overwrite, got %s", report.Summary())
+	}
+}
+
+func TestScaffoldRollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(mainPath, []byte("package old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A plain file where Standard needs the internal/repository directory
+	// makes the move into place fail after main.go was replaced.
+	if err := os.Mkdir(filepath.Join(dir, "internal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "repository"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := testSpec
+	spec.Complexity = matrix.Standard
+	_, err := Scaffold(spec, Options{OutputDir: dir, Policy: PolicyOverwrite})
+
+	var rb *RollbackError
+	if !errors.As(err, &rb) {
+		t.Fatalf("expected a rollback error, got %v", err)
+	}
+	if len(rb.Restored) != 1 || len(rb.Leftovers) != 0 {
+		t.Fatalf("unexpected rollback:\n%s", rb.Details())
+	}
+	if data, _ := os.ReadFile(mainPath); string(data) != "package old\n" {
+		t.Fatalf("main.go was not restored, got %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("expected only main.go and internal to remain, got %d entries", len(entries))
+	}
+}
+
+func TestCheckOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ok := range []string{dir, filepath.Join(dir, "new"), filepath.Join(dir, "a", "b", "c")} {
+		if err := CheckOutputDir(ok); err != nil {
+			t.Errorf("CheckOutputDir(%s): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"", file, filepath.Join(file, "sub")} {
+		if err := CheckOutputDir(bad); err == nil {
+			t.Errorf("CheckOutputDir(%q) accepted an unusable path", bad)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("CheckOutputDir left files behind: %v", entries)
+	}
+}
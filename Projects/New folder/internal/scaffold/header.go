@@ -0,0 +1,153 @@
+package scaffold
+
+import (
+	"path"
+	"strings"
+
+	"gen-code/internal/matrix"
+)
+
+// commentStyle is how a file type writes a comment. A zero style means the
+// format has no comments and gets no header.
+type commentStyle struct {
+	line        string // prefix for single-line comments
+	open, close string // block comment delimiters, used when line is empty
+}
+
+var (
+	slashComment = commentStyle{line: "// "}
+	hashComment  = commentStyle{line: "# "}
+	blockComment = commentStyle{open: "/* ", close: " */"}
+	dashComment  = commentStyle{line: "-- "}
+)
+
+// commentStyles maps file extensions to their comment syntax. Formats such
+// as JSON, Markdown and HTML are left out on purpose: a comment would make
+// them invalid or change how they are rendered.
+var commentStyles = map[string]commentStyle{
+	".go":   slashComment,
+	".js":   slashComment,
+	".cjs":  slashComment,
+	".mjs":  slashComment,
+	".ts":   slashComment,
+	".py":   hashComment,
+	".sh":   hashComment,
+	".yml":  hashComment,
+	".yaml": hashComment,
+	".toml": hashComment,
+	".cfg":  hashComment,
+	".ini":  hashComment,
+	".css":  blockComment,
+	".sql":  dashComment,
+}
+
+// commentNames covers files recognised by name rather than extension.
+var commentNames = map[string]commentStyle{
+	"Dockerfile":           hashComment,
+	"Makefile":             hashComment,
+	".dockerignore":        hashComment,
+	".gitignore":           hashComment,
+	".editorconfig":        hashComment,
+	".env":                 hashComment,
+	"requirements.txt":     hashComment,
+	"requirements-dev.txt": hashComment,
+}
+
+func styleFor(p string) commentStyle {
+	base := path.Base(p)
+	if s, ok := commentNames[base]; ok {
+		return s
+	}
+	return commentStyles[path.Ext(base)]
+}
+
+// headerLines is the signature written at the top of generated files.
+func headerLines(spec matrix.Spec) []string {
+	return []string{
+		"Code generated by Gen Code; DO NOT EDIT.",
+		"Created by: Moeed ul Hassan",
+		"Project: " + spec.AppName,
+	}
+}
+
+// withHeader returns the file content with the signature header in the
+// file's own comment syntax. Lines that must stay at the very top, a
+// shebang or Go build constraints, are kept ahead of the header.
+func withHeader(file matrix.FileTemplate, spec matrix.Spec) string {
+	style := styleFor(file.Path)
+	if file.NoHeader || style == (commentStyle{}) {
+		return file.Content
+	}
+
+	var sb strings.Builder
+	if style.line != "" {
+		for _, l := range headerLines(spec) {
+			sb.WriteString(style.line + l + "\n")
+		}
+	} else {
+		sb.WriteString(style.open + strings.Join(headerLines(spec), "\n   ") + style.close + "\n")
+	}
+
+	lead, rest := splitLead(file.Content)
+	if rest != "" {
+		sb.WriteString("\n")
+	}
+	return lead + sb.String() + rest
+}
+
+// StripHeader removes the signature header withHeader put into content,
+// for turning a generated file back into a template. Content without the
+// header comes back unchanged.
+func StripHeader(p, content string) string {
+	style := styleFor(p)
+	if style == (commentStyle{}) {
+		return content
+	}
+
+	// The last line names the project, so only its prefix is fixed.
+	lines := headerLines(matrix.Spec{})
+	lead, rest := splitLead(content)
+	if style.line != "" {
+		for _, l := range lines[:len(lines)-1] {
+			var ok bool
+			if rest, ok = strings.CutPrefix(rest, style.line+l+"\n"); !ok {
+				return content
+			}
+		}
+		if !strings.HasPrefix(rest, style.line+lines[len(lines)-1]) {
+			return content
+		}
+		_, rest, _ = strings.Cut(rest, "\n")
+	} else {
+		if !strings.HasPrefix(rest, style.open+lines[0]+"\n") {
+			return content
+		}
+		_, after, ok := strings.Cut(rest, style.close+"\n")
+		if !ok {
+			return content
+		}
+		rest = after
+	}
+	return lead + strings.TrimPrefix(rest, "\n")
+}
+
+// splitLead separates the lines that have to come before any comment: a
+// shebang, and Go build constraints together with the blank line that ends
+// them.
+func splitLead(content string) (lead, rest string) {
+	rest = content
+	if strings.HasPrefix(rest, "#!") {
+		line, after, _ := strings.Cut(rest, "\n")
+		lead, rest = line+"\n", after
+	}
+
+	for strings.HasPrefix(rest, "//go:build") || strings.HasPrefix(rest, "// +build") {
+		line, after, _ := strings.Cut(rest, "\n")
+		lead, rest = lead+line+"\n", after
+		if strings.HasPrefix(rest, "\n") {
+			lead, rest = lead+"\n", rest[1:]
+			break
+		}
+	}
+	return lead, rest
+}
@@ -0,0 +1,61 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestWithHeader(t *testing.T) {
+	spec := matrix.Spec{AppName: "demo"}
+	tests := []struct {
+		path, content string
+		wantPrefix    string
+	}{
+		{"main.go", "package main\n", "// Code generated by Gen Code; DO NOT EDIT.\n"},
+		{"app/main.py", "import os\n", "# Code generated by Gen Code; DO NOT EDIT.\n"},
+		{"static/style.css", "body {}\n", "/* Code generated by Gen Code; DO NOT EDIT.\n"},
+		{"Dockerfile", "FROM scratch\n", "# Code generated"},
+		{"package.json", "{}\n", "{}\n"},
+		{"templates/index.html", "<!DOCTYPE html>\n", "<!DOCTYPE html>\n"},
+		{"bin/cli.js", "#!/usr/bin/env node\nrun()\n", "#!/usr/bin/env node\n// Code generated"},
+		{"tools.go", "//go:build tools\n\npackage tools\n", "//go:build tools\n\n// Code generated"},
+	}
+	for _, tt := range tests {
+		got := withHeader(matrix.FileTemplate{Path: tt.path, Content: tt.content}, spec)
+		if !strings.HasPrefix(got, tt.wantPrefix) {
+			t.Errorf("%s: got\n%s", tt.path, got)
+		}
+		_, body := splitLead(tt.content)
+		if !strings.HasSuffix(got, body) {
+			t.Errorf("%s: original content not preserved:\n%s", tt.path, got)
+		}
+	}
+
+	opted := matrix.FileTemplate{Path: "main.go", Content: "package main\n", NoHeader: true}
+	if got := withHeader(opted, spec); got != opted.Content {
+		t.Errorf("NoHeader file changed:\n%s", got)
+	}
+}
+
+func TestStripHeader(t *testing.T) {
+	spec := matrix.Spec{AppName: "demo"}
+	for _, content := range []string{
+		"package main\n",
+		"#!/usr/bin/env node\nrun()\n",
+		"//go:build tools\n\npackage tools\n",
+		"body {}\n",
+		"{}\n",
+	} {
+		for _, p := range []string{"main.go", "bin/cli.js", "static/style.css", "package.json"} {
+			file := matrix.FileTemplate{Path: p, Content: content}
+			if got := StripHeader(p, withHeader(file, spec)); got != content {
+				t.Errorf("%s: got %q, want %q", p, got, content)
+			}
+			if got := StripHeader(p, content); got != content {
+				t.Errorf("%s without a header: got %q", p, got)
+			}
+		}
+	}
+}
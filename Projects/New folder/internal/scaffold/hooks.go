@@ -0,0 +1,52 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gen-code/internal/matrix"
+)
+
+// ErrHookSkipped is wrapped by RunHook's error when the hook's Unless
+// command succeeded, so the hook did not run. It is not a failure.
+var ErrHookSkipped = errors.New("skipped")
+
+// RunHook runs a post-generation hook in the project directory dir,
+// writing everything it prints to out. The error names the hook and says
+// whether it could not start, failed, ran past its timeout or was skipped.
+func RunHook(ctx context.Context, dir string, h matrix.Hook, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Limit())
+	defer cancel()
+
+	if len(h.Unless) > 0 {
+		check := exec.CommandContext(ctx, h.Unless[0], h.Unless[1:]...)
+		check.Dir = dir
+		if check.Run() == nil {
+			return fmt.Errorf("%s %w: %s succeeded", h.Name, ErrHookSkipped, strings.Join(h.Unless, " "))
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, h.Run[0], h.Run[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = out, out
+	// Children left behind by a killed hook may keep its output open.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s timed out after %s", h.Name, h.Limit())
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%s was stopped", h.Name)
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%s: %s is not installed", h.Name, h.Run[0])
+	case err != nil:
+		return fmt.Errorf("%s failed: %w", h.Name, err)
+	}
+	return nil
+}
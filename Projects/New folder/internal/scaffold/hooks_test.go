@@ -0,0 +1,37 @@
+package scaffold
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestRunHook(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		hook      matrix.Hook
+		out, want string
+	}{
+		{matrix.Hook{Name: "pwd", Run: []string{"sh", "-c", "pwd; echo done >&2"}}, dir + "\ndone\n", ""},
+		{matrix.Hook{Name: "fail", Run: []string{"sh", "-c", "exit 2"}}, "", "fail failed: exit status 2"},
+		{matrix.Hook{Name: "slow", Run: []string{"sleep", "5"}, Timeout: "100ms"}, "", "slow timed out after 100ms"},
+		{matrix.Hook{Name: "missing", Run: []string{"gen-code-no-such-tool"}}, "", "missing: gen-code-no-such-tool is not installed"},
+		{matrix.Hook{Name: "once", Run: []string{"sh", "-c", "echo ran"}, Unless: []string{"true"}}, "", "once skipped: true succeeded"},
+		{matrix.Hook{Name: "twice", Run: []string{"sh", "-c", "echo ran"}, Unless: []string{"false"}}, "ran\n", ""},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		err := RunHook(context.Background(), dir, tt.hook, &out)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: %v", tt.hook.Name, err)
+		case tt.want != "" && (err == nil || err.Error() != tt.want):
+			t.Errorf("%s: got %v, want %q", tt.hook.Name, err, tt.want)
+		}
+		if tt.out != "" && out.String() != tt.out {
+			t.Errorf("%s: output %q, want %q", tt.hook.Name, out.String(), tt.out)
+		}
+	}
+}
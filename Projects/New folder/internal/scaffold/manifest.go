@@ -0,0 +1,121 @@
+package scaffold
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gen-code/internal/matrix"
+)
+
+// ManifestFile is written at the root of every generated project.
+const ManifestFile = ".gencode.json"
+
+// snapshotFile holds every file exactly as it was generated, keyed by path.
+// It is the common ancestor gen-code upgrade merges local edits against.
+// One JSON file rather than a copy of the tree keeps test runners and
+// linters from picking the copies up.
+const snapshotFile = ".gencode/base.json"
+
+// Manifest records how a project was generated so that it can be upgraded
+// when the templates change.
+type Manifest struct {
+	TemplateSource  string      `json:"template_source"`
+	TemplateVersion string      `json:"template_version"`
+	GeneratedAt     time.Time   `json:"generated_at"`
+	Spec            matrix.Spec `json:"choices"`
+	// Files maps every generated path to the SHA-256 of its generated
+	// content.
+	Files map[string]string `json:"files"`
+
+	snapshots map[string]string
+}
+
+func newManifest(spec matrix.Spec, generated map[string]string) Manifest {
+	source, version := matrix.TemplateSource(spec)
+	m := Manifest{
+		TemplateSource:  source,
+		TemplateVersion: version,
+		GeneratedAt:     time.Now().UTC(),
+		Spec:            spec.WithDefaults(),
+		Files:           map[string]string{},
+	}
+	for p, content := range generated {
+		m.Files[p] = hash(content)
+	}
+	return m
+}
+
+// LoadManifest reads the manifest of the project in dir.
+func LoadManifest(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, fmt.Errorf("%s has no %s; only projects generated by gen-code can be upgraded", dir, ManifestFile)
+	}
+	if err != nil {
+		return m, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+
+	// Without snapshots, upgrade still works for files nobody edited.
+	data, err = os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err == nil {
+		err = json.Unmarshal(data, &m.snapshots)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return m, fmt.Errorf("failed to read %s: %w", snapshotFile, err)
+	}
+	return m, nil
+}
+
+// base returns the generated content of p as recorded by the previous run.
+// Without a snapshot, the local file still counts as the base when its hash
+// matches the manifest, i.e. when nobody edited it.
+func (m Manifest) base(p, local string) (string, bool) {
+	if content, ok := m.snapshots[p]; ok {
+		return content, true
+	}
+	if want, ok := m.Files[p]; ok && hash(local) == want {
+		return local, true
+	}
+	return "", false
+}
+
+// files returns the manifest and the snapshots to write alongside the
+// project, keyed by path.
+func (m Manifest) files(generated map[string]string) (map[string]string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	// JSON cannot hold binary content; the hash is enough for files that
+	// are never merged.
+	text := map[string]string{}
+	for p, content := range generated {
+		if !binary(content) {
+			text[p] = content
+		}
+	}
+	snapshots, err := json.MarshalIndent(text, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshots: %w", err)
+	}
+	return map[string]string{
+		ManifestFile: string(data) + "\n",
+		snapshotFile: string(snapshots) + "\n",
+	}, nil
+}
+
+func hash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
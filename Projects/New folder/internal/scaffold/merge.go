@@ -0,0 +1,63 @@
+package scaffold
+
+import (
+	"fmt"
+	"strings"
+
+	"gen-code/internal/matrix"
+)
+
+// MergeConflict is a path that two file sets both generate with different
+// content.
+type MergeConflict struct {
+	Path string
+	// Sets names the contributors: "base" for the project itself, otherwise
+	// the add-on.
+	Sets []string
+}
+
+// MergeError is returned when the selected add-ons cannot be combined.
+type MergeError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeError) Error() string {
+	parts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		parts[i] = fmt.Sprintf("%s (%s)", c.Path, strings.Join(c.Sets, " vs "))
+	}
+	return fmt.Sprintf("add-ons generate conflicting files: %s", strings.Join(parts, ", "))
+}
+
+// merge combines the base files with every add-on set. A path generated
+// twice with identical content, such as a shared tests/__init__.py, is
+// written once; with different content it is a conflict.
+func merge(m matrix.ProjectMatrix) ([]matrix.FileTemplate, error) {
+	files := append([]matrix.FileTemplate(nil), m.Files...)
+	owner := map[string]string{}
+	index := map[string]int{}
+	for i, f := range files {
+		owner[f.Path] = "base"
+		index[f.Path] = i
+	}
+
+	var conflicts []MergeConflict
+	for _, set := range m.Addons {
+		for _, f := range set.Files {
+			i, seen := index[f.Path]
+			switch {
+			case !seen:
+				owner[f.Path] = string(set.Addon)
+				index[f.Path] = len(files)
+				files = append(files, f)
+			case files[i].Content != f.Content || files[i].Mode != f.Mode:
+				conflicts = append(conflicts, MergeConflict{Path: f.Path, Sets: []string{owner[f.Path], string(set.Addon)}})
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &MergeError{Conflicts: conflicts}
+	}
+	return files, nil
+}
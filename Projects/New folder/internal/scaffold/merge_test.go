@@ -0,0 +1,52 @@
+package scaffold
+
+import (
+	"errors"
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestMerge(t *testing.T) {
+	m := matrix.ProjectMatrix{
+		Files: []matrix.FileTemplate{
+			{Path: "main.go", Content: "package main\n"},
+			{Path: "tests/__init__.py", Content: ""},
+		},
+		Addons: []matrix.FileSet{
+			{Addon: matrix.Tests, Files: []matrix.FileTemplate{
+				{Path: "tests/__init__.py", Content: ""},
+				{Path: "tests/test_health.py", Content: "import unittest\n"},
+			}},
+			{Addon: matrix.Docker, Files: []matrix.FileTemplate{{Path: "Dockerfile", Content: "FROM scratch\n"}}},
+		},
+	}
+
+	files, err := merge(m)
+	if err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"main.go", "tests/__init__.py", "tests/test_health.py", "Dockerfile"}
+	if len(paths) != len(want) {
+		t.Fatalf("merged paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("merged paths = %v, want %v", paths, want)
+		}
+	}
+
+	m.Addons = append(m.Addons, matrix.FileSet{Addon: matrix.Lint, Files: []matrix.FileTemplate{{Path: "Dockerfile", Content: "FROM alpine\n"}}})
+	_, err = merge(m)
+	var merr *MergeError
+	if !errors.As(err, &merr) || len(merr.Conflicts) != 1 {
+		t.Fatalf("expected one merge conflict, got %v", err)
+	}
+	if c := merr.Conflicts[0]; c.Path != "Dockerfile" || c.Sets[0] != "Docker" || c.Sets[1] != "Lint" {
+		t.Errorf("unexpected conflict %+v", c)
+	}
+}
@@ -0,0 +1,126 @@
+package scaffold
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionOverwrite Action = "overwrite"
+	ActionNewCopy   Action = "new copy"
+	ActionSkip      Action = "skip"
+	ActionUnchanged Action = "unchanged"
+	// ActionConflict marks files that stopped an aborted run.
+	ActionConflict Action = "conflict"
+	// ActionExcluded marks files left out with Options.Exclude.
+	ActionExcluded Action = "excluded"
+
+	// Upgrades only.
+	ActionUpdate        Action = "update"
+	ActionMerged        Action = "merged"
+	ActionMergeConflict Action = "merge conflict"
+	// ActionObsolete marks files the templates no longer generate. They are
+	// left in place.
+	ActionObsolete Action = "obsolete"
+)
+
+// Entry describes what happened (or, in a dry run, would happen) to one file.
+type Entry struct {
+	Path   string
+	Action Action
+	// WrittenTo differs from Path when the file went to a .new copy.
+	WrittenTo string
+	// Diff against the existing file, set whenever one was in the way.
+	Diff string
+}
+
+type Report struct {
+	OutputDir string
+	DryRun    bool
+	Entries   []Entry
+}
+
+// Count returns how many entries ended with the given action.
+func (r *Report) Count(a Action) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary is a one-line tally such as "3 create, 1 skip".
+func (r *Report) Summary() string {
+	var parts []string
+	for _, a := range []Action{ActionCreate, ActionUpdate, ActionOverwrite, ActionMerged, ActionNewCopy, ActionSkip, ActionExcluded, ActionUnchanged, ActionObsolete, ActionConflict, ActionMergeConflict} {
+		if n := r.Count(a); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, a))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+
+	s := strings.Join(parts, ", ")
+	if r.DryRun {
+		s += " (dry run, nothing written)"
+	}
+	return s
+}
+
+// Tree renders the entries as an indented file tree, each file tagged with
+// its action.
+func (r *Report) Tree() string {
+	entries := append([]Entry(nil), r.Entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	var sb strings.Builder
+	sb.WriteString(r.OutputDir + "/\n")
+
+	var prev []string
+	for _, e := range entries {
+		parts := strings.Split(e.Path, "/")
+		dirs := parts[:len(parts)-1]
+
+		// Print only the directories that differ from the previous file.
+		common := 0
+		for common < len(dirs) && common < len(prev) && dirs[common] == prev[common] {
+			common++
+		}
+		for i := common; i < len(dirs); i++ {
+			fmt.Fprintf(&sb, "%s%s/\n", strings.Repeat("  ", i+1), dirs[i])
+		}
+		prev = dirs
+
+		label := string(e.Action)
+		if e.WrittenTo != "" && e.WrittenTo != e.Path {
+			label += " -> " + e.WrittenTo
+		}
+		fmt.Fprintf(&sb, "%s%s  [%s]\n", strings.Repeat("  ", len(dirs)+1), parts[len(parts)-1], label)
+	}
+
+	return sb.String()
+}
+
+// Diffs concatenates the diff of every file that collided with an existing one.
+func (r *Report) Diffs() string {
+	var sb strings.Builder
+	for _, e := range r.Entries {
+		sb.WriteString(e.Diff)
+	}
+	return sb.String()
+}
+
+func (r *Report) String() string {
+	s := r.Tree() + "\n" + r.Summary() + "\n"
+	if d := r.Diffs(); d != "" {
+		s += "\n" + d
+	}
+	return s
+}
@@ -0,0 +1,309 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing/fstest"
+	"time"
+)
+
+// Sink is where Scaffold puts a generated project. Paths are slash
+// separated and relative to the project root.
+type Sink interface {
+	// ReadFile returns what path holds already, or an error matching
+	// fs.ErrNotExist. Sinks that start out empty always return the latter.
+	ReadFile(path string) ([]byte, error)
+
+	// Write stores the files: all of them, or none when it fails.
+	Write(files []File) error
+}
+
+// File is one file handed to a Sink.
+type File struct {
+	Path string
+	Data []byte
+	// Mode holds the permission bits; zero means 0644. fs.ModeSymlink
+	// makes the file a symbolic link to the slash-separated path in Data.
+	Mode fs.FileMode
+}
+
+func (f File) link() bool { return f.Mode&fs.ModeSymlink != 0 }
+
+func (f File) perm() fs.FileMode {
+	if f.Mode == 0 {
+		return 0644
+	}
+	return f.Mode.Perm()
+}
+
+// Dir writes into a directory on disk. Files are written to a staging
+// directory next to it first and only then moved into place, and a failure
+// part way rolls everything back.
+func Dir(dir string) Sink { return dirSink(dir) }
+
+type dirSink string
+
+func (d dirSink) ReadFile(p string) ([]byte, error) {
+	// A link reads as its target, the way it is generated.
+	full := filepath.Join(string(d), filepath.FromSlash(p))
+	if info, err := os.Lstat(full); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(full)
+		return []byte(filepath.ToSlash(target)), err
+	}
+	data, err := os.ReadFile(full)
+	if errors.Is(err, syscall.ENOTDIR) {
+		// A file blocking a parent directory is caught when the project is
+		// moved into place.
+		err = fs.ErrNotExist
+	}
+	return data, err
+}
+
+func (d dirSink) Write(files []File) error {
+	tx, err := begin(string(d))
+	if err != nil {
+		return err
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		if err := tx.stage(f); err != nil {
+			return tx.fail(err)
+		}
+		paths[i] = f.Path
+	}
+	return tx.commit(paths)
+}
+
+// Zip writes the project as a zip archive to w, with every path under
+// root when it is not empty.
+func Zip(w io.Writer, root string) Sink { return &zipSink{w: w, root: root} }
+
+type zipSink struct {
+	w    io.Writer
+	root string
+}
+
+func (z *zipSink) ReadFile(p string) ([]byte, error) { return nil, notExist(p) }
+
+func (z *zipSink) Write(files []File) error {
+	zw := zip.NewWriter(z.w)
+	now := time.Now()
+	for _, f := range files {
+		h := &zip.FileHeader{Name: path.Join(z.root, f.Path), Method: zip.Deflate, Modified: now}
+		if f.link() {
+			// Unzip tools read a link's target from its data.
+			h.Method = zip.Store
+			h.SetMode(fs.ModeSymlink | 0777)
+		} else {
+			h.SetMode(f.perm())
+		}
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to the archive: %w", f.Path, err)
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return fmt.Errorf("failed to add %s to the archive: %w", f.Path, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish the archive: %w", err)
+	}
+	return nil
+}
+
+// TarGz writes the project as a gzipped tarball to w, with every path
+// under root when it is not empty.
+func TarGz(w io.Writer, root string) Sink { return &tarSink{w: w, root: root} }
+
+type tarSink struct {
+	w    io.Writer
+	root string
+}
+
+func (t *tarSink) ReadFile(p string) ([]byte, error) { return nil, notExist(p) }
+
+func (t *tarSink) Write(files []File) error {
+	gz := gzip.NewWriter(t.w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	// Directories get entries of their own so that every tool extracts
+	// them with sensible permissions.
+	dirs := map[string]bool{}
+	for _, f := range files {
+		var parents []string
+		for dir := path.Dir(path.Join(t.root, f.Path)); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+			parents = append(parents, dir)
+		}
+		for i := len(parents) - 1; i >= 0; i-- {
+			h := &tar.Header{Typeflag: tar.TypeDir, Name: parents[i] + "/", Mode: 0755, ModTime: now}
+			if err := tw.WriteHeader(h); err != nil {
+				return fmt.Errorf("failed to add %s to the archive: %w", parents[i], err)
+			}
+		}
+
+		h := &tar.Header{Typeflag: tar.TypeReg, Name: path.Join(t.root, f.Path), Mode: int64(f.perm()), Size: int64(len(f.Data)), ModTime: now}
+		if f.link() {
+			h.Typeflag, h.Linkname, h.Mode, h.Size = tar.TypeSymlink, string(f.Data), 0777, 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return fmt.Errorf("failed to add %s to the archive: %w", f.Path, err)
+		}
+		if f.link() {
+			continue
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return fmt.Errorf("failed to add %s to the archive: %w", f.Path, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish the archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish the archive: %w", err)
+	}
+	return nil
+}
+
+// MemorySink keeps the project in memory. Later runs into the same sink
+// see what earlier ones wrote, so conflict policies work as they do on
+// disk.
+type MemorySink struct {
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+func Memory() *MemorySink { return &MemorySink{files: fstest.MapFS{}} }
+
+func (m *MemorySink) ReadFile(p string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[p]
+	if !ok {
+		return nil, notExist(p)
+	}
+	return f.Data, nil
+}
+
+func (m *MemorySink) Write(files []File) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, f := range files {
+		if !fs.ValidPath(f.Path) {
+			return fmt.Errorf("invalid path %q", f.Path)
+		}
+	}
+	now := time.Now()
+	for _, f := range files {
+		mode := f.perm()
+		if f.link() {
+			mode = fs.ModeSymlink | 0777
+		}
+		m.files[f.Path] = &fstest.MapFile{Data: f.Data, Mode: mode, ModTime: now}
+	}
+	return nil
+}
+
+// FS returns a snapshot of the files written so far.
+func (m *MemorySink) FS() fs.FS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(fstest.MapFS, len(m.files))
+	for p, f := range m.files {
+		copied := *f
+		snapshot[p] = &copied
+	}
+	return snapshot
+}
+
+// ArchiveFormat returns "zip" or "tar.gz" when output names an archive of
+// that kind, and "" for a directory.
+func ArchiveFormat(output string) string {
+	lower := strings.ToLower(output)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// Archive returns the sink for an archive in the given format, as
+// ArchiveFormat names it.
+func Archive(format string, w io.Writer, root string) (Sink, error) {
+	switch format {
+	case "zip":
+		return Zip(w, root), nil
+	case "tar.gz":
+		return TarGz(w, root), nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q (valid: zip, tar.gz)", format)
+}
+
+// ArchiveFile writes the project as an archive at file, in the format its
+// extension names, with every path under a directory named like the
+// archive: my-api.zip holds my-api/go.mod. An existing archive is
+// replaced, but only once the new one is complete.
+func ArchiveFile(file string) (Sink, error) {
+	format := ArchiveFormat(file)
+	if format == "" {
+		return nil, fmt.Errorf("%s is not a .zip, .tar.gz or .tgz file", file)
+	}
+	if info, err := os.Stat(filepath.Dir(file)); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("cannot write %s: %s is not a directory", file, filepath.Dir(file))
+	}
+	root := filepath.Base(file)
+	root = root[:len(root)-len(path.Ext(root))]
+	root = strings.TrimSuffix(root, ".tar")
+	return &archiveFile{path: file, format: format, root: root}, nil
+}
+
+type archiveFile struct {
+	path, format, root string
+}
+
+func (a *archiveFile) ReadFile(p string) ([]byte, error) { return nil, notExist(p) }
+
+func (a *archiveFile) Write(files []File) error {
+	tmp, err := os.CreateTemp(filepath.Dir(a.path), "."+filepath.Base(a.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create archive %s: %w", a.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	sink, err := Archive(a.format, tmp, a.root)
+	if err == nil {
+		err = sink.Write(files)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write archive %s: %w", a.path, err)
+	}
+	// CreateTemp makes the file private to its owner.
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), a.path)
+}
+
+func notExist(p string) error {
+	return &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+}
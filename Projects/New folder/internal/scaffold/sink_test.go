@@ -0,0 +1,187 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMemorySink(t *testing.T) {
+	sink := Memory()
+	if _, err := Scaffold(testSpec, Options{Sink: sink}); err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+	main, err := fs.ReadFile(sink.FS(), "main.go")
+	if err != nil || !strings.Contains(string(main), "package main") {
+		t.Fatalf("main.go = %q, %v", main, err)
+	}
+	if _, err := fs.Stat(sink.FS(), ManifestFile); err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+
+	// A second run sees the first one's files.
+	sink.Write([]File{{Path: "main.go", Data: []byte("package old\n")}})
+	_, err = Scaffold(testSpec, Options{Sink: sink})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !slices.Equal(conflict.Paths, []string{"main.go"}) {
+		t.Fatalf("expected a conflict on main.go, got %v", err)
+	}
+}
+
+func TestArchiveSinks(t *testing.T) {
+	var zipped, tarred bytes.Buffer
+	if _, err := Scaffold(testSpec, Options{Sink: Zip(&zipped, "demo")}); err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	if _, err := Scaffold(testSpec, Options{Sink: TarGz(&tarred, "demo")}); err != nil {
+		t.Fatalf("tar.gz: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zipNames []string
+	for _, f := range zr.File {
+		zipNames = append(zipNames, f.Name)
+	}
+
+	gz, err := gzip.NewReader(&tarred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var tarNames []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			tarNames = append(tarNames, h.Name)
+		}
+	}
+
+	for _, names := range [][]string{zipNames, tarNames} {
+		if !slices.Contains(names, "demo/main.go") || !slices.Contains(names, "demo/"+ManifestFile) {
+			t.Errorf("archive is missing demo/main.go or the manifest: %v", names)
+		}
+	}
+	if !slices.Equal(zipNames, tarNames) {
+		t.Errorf("zip holds %v, tar.gz %v", zipNames, tarNames)
+	}
+}
+
+func TestSinksKeepModes(t *testing.T) {
+	files := []File{{Path: "run.sh", Data: []byte("#!/bin/sh\n"), Mode: 0755}, {Path: "README.md", Data: []byte("# demo\n")}}
+	want := map[string]fs.FileMode{"run.sh": 0755, "README.md": 0644}
+
+	dir := t.TempDir()
+	if err := Dir(dir).Write(files); err != nil {
+		t.Fatal(err)
+	}
+	var zipped, tarred bytes.Buffer
+	if err := Zip(&zipped, "").Write(files); err != nil {
+		t.Fatal(err)
+	}
+	if err := TarGz(&tarred, "").Write(files); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&tarred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	for i, f := range zr.File {
+		h, err := tr.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Mode().Perm() != want[f.Name] || fs.FileMode(h.Mode) != want[h.Name] {
+			t.Errorf("%s: zip mode %v, tar mode %v", files[i].Path, f.Mode().Perm(), fs.FileMode(h.Mode))
+		}
+	}
+	// The umask may take away group and other bits, never the owner's.
+	if info, err := os.Stat(filepath.Join(dir, "run.sh")); err != nil || info.Mode().Perm()&0700 != 0700 {
+		t.Errorf("run.sh on disk: %v, %v", info, err)
+	}
+}
+
+func TestSinksWriteLinks(t *testing.T) {
+	files := []File{{Path: "README.md", Data: []byte("# demo\n")}, {Path: "docs/README.md", Data: []byte("../README.md"), Mode: fs.ModeSymlink}}
+
+	dir := t.TempDir()
+	if err := Dir(dir).Write(files); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "docs", "README.md")); err != nil || target != filepath.FromSlash("../README.md") {
+		t.Errorf("link on disk: %q, %v", target, err)
+	}
+	if data, err := Dir(dir).ReadFile("docs/README.md"); err != nil || string(data) != "../README.md" {
+		t.Errorf("ReadFile reads the link as %q, %v, want its target", data, err)
+	}
+
+	var zipped, tarred bytes.Buffer
+	if err := Zip(&zipped, "").Write(files); err != nil {
+		t.Fatal(err)
+	}
+	if err := TarGz(&tarred, "").Write(files); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := zr.File[1]
+	data, _ := fs.ReadFile(zr, link.Name)
+	if link.Mode()&fs.ModeSymlink == 0 || string(data) != "../README.md" {
+		t.Errorf("zip: mode %v, target %q", link.Mode(), data)
+	}
+	gz, err := gzip.NewReader(&tarred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var h *tar.Header
+	for {
+		if h, err = tr.Next(); err != nil || h.Name == "docs/README.md" {
+			break
+		}
+	}
+	if err != nil || h.Typeflag != tar.TypeSymlink || h.Linkname != "../README.md" {
+		t.Errorf("tar: %+v, %v", h, err)
+	}
+
+	mem := Memory()
+	if err := mem.Write(files); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := fs.ReadLink(mem.FS(), "docs/README.md"); err != nil || target != "../README.md" {
+		t.Errorf("memory: %q, %v", target, err)
+	}
+}
+
+func TestArchiveFormat(t *testing.T) {
+	for output, want := range map[string]string{"out/demo.zip": "zip", "demo.TGZ": "tar.gz", "demo.tar.gz": "tar.gz", "demo": "", "demo.tar": ""} {
+		if got := ArchiveFormat(output); got != want {
+			t.Errorf("ArchiveFormat(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
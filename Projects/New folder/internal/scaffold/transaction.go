@@ -0,0 +1,277 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// RollbackError is returned when scaffolding failed part way and everything
+// it had already done was undone. It lists exactly what was cleaned up.
+type RollbackError struct {
+	Err error
+	// Removed holds files and directories deleted during the rollback,
+	// relative to the working directory.
+	Removed []string
+	// Restored holds existing files that had been replaced and were put back.
+	Restored []string
+	// Leftovers could not be cleaned up and need manual attention.
+	Leftovers []string
+}
+
+func (e *RollbackError) Error() string {
+	msg := fmt.Sprintf("scaffolding failed and was rolled back (%d removed, %d restored): %v", len(e.Removed), len(e.Restored), e.Err)
+	if len(e.Leftovers) > 0 {
+		msg += fmt.Sprintf("; could not clean up: %s", strings.Join(e.Leftovers, ", "))
+	}
+	return msg
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// Details lists every rolled back path, one per line.
+func (e *RollbackError) Details() string {
+	var sb strings.Builder
+	for _, p := range e.Removed {
+		sb.WriteString("  removed  " + p + "\n")
+	}
+	for _, p := range e.Restored {
+		sb.WriteString("  restored " + p + "\n")
+	}
+	for _, p := range e.Leftovers {
+		sb.WriteString("  LEFT     " + p + "\n")
+	}
+	return sb.String()
+}
+
+// transaction writes a project into a staging directory next to the output
+// directory and only then moves it into place, so a failure never leaves a
+// half-written project behind.
+type transaction struct {
+	outputDir string
+	base      string // parent of outputDir
+	cwd       string // paths in errors are relative to it
+	staging   string
+
+	createdDirs []string          // directories we created, in creation order
+	placed      []string          // files moved into outputDir
+	backups     map[string]string // replaced file -> its backup in staging
+	movedWhole  bool              // staging itself was renamed to outputDir
+}
+
+func begin(outputDir string) (*transaction, error) {
+	abs, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output path %s: %w", outputDir, err)
+	}
+
+	cwd, _ := os.Getwd()
+	t := &transaction{outputDir: abs, base: filepath.Dir(abs), cwd: cwd, backups: map[string]string{}}
+
+	// The staging directory must live on the same filesystem as the output
+	// for the final renames to be atomic, so it goes right next to it.
+	if err := t.mkdirAll(t.base); err != nil {
+		return nil, t.fail(fmt.Errorf("failed to create %s: %w", t.base, err))
+	}
+	t.staging, err = os.MkdirTemp(t.base, ".gen-code-staging-*")
+	if err != nil {
+		return nil, t.fail(fmt.Errorf("failed to create staging directory: %w", err))
+	}
+
+	return t, nil
+}
+
+// mkdirAll works like os.MkdirAll but remembers every directory it creates.
+func (t *transaction) mkdirAll(dir string) error {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil {
+			return err
+		}
+		t.createdDirs = append(t.createdDirs, missing[i])
+	}
+	return nil
+}
+
+func (t *transaction) stage(f File) error {
+	full := filepath.Join(t.staging, f.Path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+	}
+	if f.link() {
+		if err := os.Symlink(filepath.FromSlash(string(f.Data)), full); err != nil {
+			return fmt.Errorf("failed to create link %s: %w", f.Path, err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(full, f.Data, f.perm()); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// commit moves the staged files into the output directory.
+func (t *transaction) commit(paths []string) error {
+	// Fresh output: one atomic rename moves the whole project into place.
+	if _, err := os.Stat(t.outputDir); errors.Is(err, fs.ErrNotExist) {
+		if err := os.Chmod(t.staging, 0755); err != nil {
+			return t.fail(err)
+		}
+		if err := os.Rename(t.staging, t.outputDir); err != nil {
+			return t.fail(fmt.Errorf("failed to move project into %s: %w", t.outputDir, err))
+		}
+		t.movedWhole = true
+		return nil
+	}
+
+	// Existing output: move file by file, keeping a backup of anything
+	// replaced so a later failure can put it back.
+	for _, path := range paths {
+		staged := filepath.Join(t.staging, path)
+		target := filepath.Join(t.outputDir, path)
+
+		if err := t.mkdirAll(filepath.Dir(target)); err != nil {
+			return t.fail(fmt.Errorf("failed to create directory for %s: %w", path, err))
+		}
+
+		if _, err := os.Lstat(target); err == nil {
+			backup := filepath.Join(t.staging, ".backup", path)
+			if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+				return t.fail(err)
+			}
+			if err := os.Rename(target, backup); err != nil {
+				return t.fail(fmt.Errorf("failed to back up %s: %w", path, err))
+			}
+			t.backups[target] = backup
+		}
+
+		if err := os.Rename(staged, target); err != nil {
+			return t.fail(fmt.Errorf("failed to move %s into place: %w", path, err))
+		}
+		t.placed = append(t.placed, target)
+	}
+
+	os.RemoveAll(t.staging)
+	return nil
+}
+
+// fail undoes everything done so far and wraps err in a RollbackError.
+func (t *transaction) fail(err error) error {
+	rb := &RollbackError{Err: err}
+
+	if t.movedWhole {
+		t.remove(rb, t.outputDir, os.RemoveAll)
+	}
+
+	for i := len(t.placed) - 1; i >= 0; i-- {
+		target := t.placed[i]
+		if _, replaced := t.backups[target]; !replaced {
+			t.remove(rb, target, os.Remove)
+		}
+	}
+	replaced := make([]string, 0, len(t.backups))
+	for target := range t.backups {
+		replaced = append(replaced, target)
+	}
+	sort.Strings(replaced)
+	for _, target := range replaced {
+		if e := os.Rename(t.backups[target], target); e != nil {
+			rb.Leftovers = append(rb.Leftovers, t.rel(target))
+			continue
+		}
+		rb.Restored = append(rb.Restored, t.rel(target))
+	}
+
+	if t.staging != "" {
+		os.RemoveAll(t.staging)
+	}
+
+	for i := len(t.createdDirs) - 1; i >= 0; i-- {
+		t.remove(rb, t.createdDirs[i], os.Remove)
+	}
+
+	return rb
+}
+
+func (t *transaction) remove(rb *RollbackError, path string, rm func(string) error) {
+	if err := rm(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		rb.Leftovers = append(rb.Leftovers, t.rel(path))
+		return
+	}
+	rb.Removed = append(rb.Removed, t.rel(path))
+}
+
+func (t *transaction) rel(path string) string {
+	if r, err := filepath.Rel(t.cwd, path); err == nil && t.cwd != "" {
+		return r
+	}
+	return path
+}
+
+// CheckOutputDir reports whether a project can be generated into dir
+// without touching anything: dir must be a directory or not exist yet, and
+// the staging directory next to it must be creatable.
+func CheckOutputDir(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return errors.New("output path is required")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output path %s: %w", dir, err)
+	}
+
+	if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s exists and is not a directory", dir)
+	} else if err != nil && !missing(err) {
+		return fmt.Errorf("cannot use %s: %w", dir, err)
+	}
+
+	// Find the directory the staging area, and any missing parents, would
+	// be created in.
+	existing := filepath.Dir(abs)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("cannot create %s: %s is not a directory", dir, existing)
+			}
+			break
+		}
+		if !missing(err) {
+			return fmt.Errorf("cannot use %s: %w", dir, err)
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	probe, err := os.CreateTemp(existing, ".gen-code-probe-*")
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %s is not writable", dir, existing)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// missing reports whether a stat error means the path does not exist,
+// including a file standing where a parent directory should be.
+func missing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+}
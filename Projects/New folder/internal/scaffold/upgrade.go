@@ -0,0 +1,102 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"gen-code/internal/matrix"
+)
+
+// Upgrade re-renders the project in opts.OutputDir from the choices in its
+// manifest with the current templates. Files nobody edited are replaced;
+// edited files get the template changes merged in, with conflict markers
+// where both sides changed the same lines. opts.Policy is not used: local
+// edits are never simply overwritten.
+func Upgrade(opts Options) (*Report, error) {
+	manifest, err := LoadManifest(opts.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	spec := manifest.Spec
+	if source, _ := matrix.TemplateSource(spec); source != manifest.TemplateSource {
+		return nil, fmt.Errorf("%s was generated from %s templates, but %s/%s now comes from %s", opts.OutputDir, manifest.TemplateSource, spec.Language, spec.Framework, source)
+	}
+
+	files, err := generate(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{OutputDir: opts.OutputDir, DryRun: opts.DryRun}
+	contents := map[string]string{}
+	generated := map[string]string{}
+	modes := map[string]fs.FileMode{}
+
+	for _, file := range files {
+		content := withHeader(file, spec)
+		generated[file.Path] = content
+		modes[file.Path] = file.Mode
+		entry := Entry{Path: file.Path, Action: ActionCreate, WrittenTo: file.Path}
+
+		data, err := Dir(opts.OutputDir).ReadFile(file.Path)
+		local := string(data)
+		switch {
+		case err != nil && missing(err):
+			// A tracked file that is gone was deleted on purpose.
+			if _, tracked := manifest.Files[file.Path]; tracked {
+				entry.Action, entry.WrittenTo = ActionSkip, ""
+			}
+		case err != nil:
+			return report, fmt.Errorf("failed to read existing %s: %w", file.Path, err)
+		case local == content:
+			entry.Action, entry.WrittenTo = ActionUnchanged, ""
+		default:
+			base, known := manifest.base(file.Path, local)
+			switch {
+			case known && base == local:
+				entry.Action = ActionUpdate
+			case known && base == content, binary(local) || binary(content), file.Mode&fs.ModeSymlink != 0:
+				// Only the user changed it, or it is binary or a link and
+				// cannot be merged; their version stays.
+				entry.Action, entry.WrittenTo = ActionUnchanged, ""
+			default:
+				merged, conflicts := merge3(base, local, content)
+				content = merged
+				switch {
+				case conflicts > 0:
+					entry.Action = ActionMergeConflict
+				case merged == local:
+					// The user already made the template's changes.
+					entry.Action, entry.WrittenTo = ActionUnchanged, ""
+				default:
+					entry.Action = ActionMerged
+				}
+			}
+			if entry.WrittenTo != "" {
+				entry.Diff = unifiedDiff(file.Path, local, content)
+			}
+		}
+
+		report.Entries = append(report.Entries, entry)
+		if entry.WrittenTo != "" {
+			contents[entry.WrittenTo] = content
+		}
+	}
+
+	for _, p := range slices.Sorted(maps.Keys(manifest.Files)) {
+		if _, ok := generated[p]; !ok {
+			report.Entries = append(report.Entries, Entry{Path: p, Action: ActionObsolete})
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	extra, err := newManifest(spec, generated).files(generated)
+	if err != nil {
+		return report, err
+	}
+	return report, write(Dir(opts.OutputDir), report, contents, modes, extra)
+}
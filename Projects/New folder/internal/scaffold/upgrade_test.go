@@ -0,0 +1,96 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		base, local, generated string
+		want                   string
+		conflicts              int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\n", 0},
+		{"a\nb\nc\n", "a\nb\nc\nmine\n", "A\nb\nc\n", "A\nb\nc\nmine\n", 0},
+		{"a\nb\nc\n", "a\nx\nc\n", "a\nx\nc\n", "a\nx\nc\n", 0},
+		{"a\nb\nc\n", "a\nmine\nc\n", "a\ntheirs\nc\n", "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> generated\nc\n", 1},
+		{"", "mine\n", "theirs\n", "<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> generated\n", 1},
+	}
+	for _, tt := range tests {
+		got, n := merge3(tt.base, tt.local, tt.generated)
+		if got != tt.want || n != tt.conflicts {
+			t.Errorf("merge3(%q, %q, %q) = %q, %d; want %q, %d", tt.base, tt.local, tt.generated, got, n, tt.want, tt.conflicts)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Scaffold(testSpec, Options{OutputDir: dir}); err != nil {
+		t.Fatalf("scaffold failed: %v", err)
+	}
+	if _, err := LoadManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(p string) string {
+		data, err := os.ReadFile(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	write := func(p, content string) {
+		if err := os.WriteFile(filepath.Join(dir, p), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot := func(p, content string) {
+		var snapshots map[string]string
+		if err := json.Unmarshal([]byte(read(snapshotFile)), &snapshots); err != nil {
+			t.Fatal(err)
+		}
+		snapshots[p] = content
+		data, _ := json.Marshal(snapshots)
+		write(snapshotFile, string(data))
+	}
+
+	// Pretend the project came from older templates: go.mod was left alone,
+	// main.go was edited below the line the templates since changed.
+	mainGo, goMod := read("main.go"), read("go.mod")
+	oldMain := strings.Replace(mainGo, "package main", "package main // old", 1)
+	oldMod := goMod + "// old\n"
+	snapshot("main.go", oldMain)
+	snapshot("go.mod", oldMod)
+	write("main.go", oldMain+"// mine\n")
+	write("go.mod", oldMod)
+
+	report, err := Upgrade(Options{OutputDir: dir})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if report.Count(ActionMerged) != 1 || report.Count(ActionUpdate) != 1 {
+		t.Fatalf("expected one merge and one update, got %s", report.Summary())
+	}
+	if got := read("main.go"); got != mainGo+"// mine\n" {
+		t.Errorf("main.go not merged:\n%s", got)
+	}
+	if m, err := LoadManifest(dir); err != nil || read("go.mod") != goMod || m.snapshots["main.go"] != mainGo {
+		t.Errorf("go.mod or the snapshot was not brought up to date (%v)", err)
+	}
+
+	// Both sides changing the same line is marked, not overwritten.
+	snapshot("main.go", oldMain)
+	write("main.go", strings.Replace(mainGo, "package main", "package main // mine", 1))
+	report, err = Upgrade(Options{OutputDir: dir})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+	if report.Count(ActionMergeConflict) != 1 || !strings.Contains(read("main.go"), "package main // mine\n=======\npackage main\n") {
+		t.Fatalf("expected conflict markers in main.go, got %s:\n%s", report.Summary(), read("main.go"))
+	}
+}
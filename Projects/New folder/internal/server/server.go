@@ -0,0 +1,210 @@
+// Package server serves gen-code over HTTP for people who do not have the
+// binary: GET /api/options lists what can be generated and POST
+// /api/generate answers with the project as a zip archive.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"gen-code/internal/answers"
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+)
+
+type Options struct {
+	// MaxBodyBytes caps the size of a generation request.
+	MaxBodyBytes int64
+	// MaxConcurrent caps how many projects are generated at once. Requests
+	// over the limit are turned away with 503 rather than queued.
+	MaxConcurrent int
+}
+
+type Server struct {
+	opts  Options
+	slots chan struct{}
+}
+
+func New(opts Options) *Server {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 64 << 10
+	}
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 1
+	}
+	return &Server{opts: opts, slots: make(chan struct{}, opts.MaxConcurrent)}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /api/options", s.options)
+	mux.HandleFunc("POST /api/generate", s.generate)
+	return mux
+}
+
+// Option is a named choice with its description, as the wizard shows it.
+type Option struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type AddonOption struct {
+	Option
+	// Group names mutually exclusive add-ons, such as the databases.
+	Group string `json:"group,omitempty"`
+}
+
+type ComplexityOption struct {
+	Option
+	Addons []AddonOption `json:"addons"`
+}
+
+type FrameworkOption struct {
+	Option
+	Pack         string             `json:"pack,omitempty"`
+	ProjectTypes []Option           `json:"project_types"`
+	Complexities []ComplexityOption `json:"complexities"`
+}
+
+type LanguageOption struct {
+	Option
+	Frameworks []FrameworkOption `json:"frameworks"`
+}
+
+// Catalog lists every combination the registry offers, nested the way the
+// wizard asks for them.
+func Catalog(reg *matrix.Registry) []LanguageOption {
+	var langs []LanguageOption
+	for _, l := range reg.Languages() {
+		lang := LanguageOption{Option: Option{string(l.Name), l.Description}}
+		for _, fw := range reg.Frameworks(l.Name) {
+			f := FrameworkOption{Option: Option{string(fw.Name), fw.Description}, Pack: fw.Pack}
+			for _, pt := range reg.ProjectTypes(l.Name, fw.Name) {
+				f.ProjectTypes = append(f.ProjectTypes, Option{string(pt.Name), pt.Description})
+			}
+			for _, c := range reg.Complexities(l.Name, fw.Name) {
+				co := ComplexityOption{Option: Option{string(c.Name), c.Description}, Addons: []AddonOption{}}
+				for _, a := range reg.Addons(l.Name, fw.Name, c.Name) {
+					co.Addons = append(co.Addons, AddonOption{Option: Option{string(a.Name), a.Description}, Group: a.Group})
+				}
+				f.Complexities = append(f.Complexities, co)
+			}
+			lang.Frameworks = append(lang.Frameworks, f)
+		}
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+func (s *Server) options(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"languages": Catalog(matrix.DefaultRegistry())})
+}
+
+// generate takes the fields of an answers file as JSON and streams the
+// project back as a zip archive.
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many projects are being generated; try again shortly"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	var a answers.Answers
+	if err := dec.Decode(&a); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request is larger than %d bytes", tooBig.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request is not a valid answers object: %w", err))
+		return
+	}
+	// Both name files, and the server must not read its own disk for
+	// whoever asks.
+	if a.OpenAPI != "" || a.Entities != "" {
+		writeError(w, http.StatusBadRequest, errors.New("openapi and entities name local files and cannot be used over HTTP"))
+		return
+	}
+
+	// The module path ends up in file contents, so reject it before it
+	// reaches a template.
+	if a.ModulePath != "" {
+		if err := matrix.ValidateModulePath(a.ModulePath); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	a, err := a.Validate()
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":    "invalid answers",
+			"problems": strings.Split(err.Error(), "\n"),
+		})
+		return
+	}
+
+	// The archive is named like the project's directory would be, after
+	// the validated app name.
+	root := matrix.Kebab(a.AppName)
+	sink := &zipResponse{w: w, name: root}
+	if _, err := scaffold.Scaffold(a.Spec(), scaffold.Options{OutputDir: root, Sink: sink}); err != nil {
+		log.Printf("gen-code serve: generating %s failed: %v", root, err)
+		if !sink.started {
+			// Template errors say nothing the client can act on.
+			writeError(w, http.StatusInternalServerError, errors.New("failed to generate the project"))
+		}
+		// Otherwise the status is sent; all that is left is to cut the
+		// archive short.
+	}
+}
+
+// zipResponse streams the project to the client. Headers go out with the
+// first file, so a project that fails to render still gets an error status.
+type zipResponse struct {
+	w       http.ResponseWriter
+	name    string
+	started bool
+}
+
+func (z *zipResponse) ReadFile(p string) ([]byte, error) {
+	return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+}
+
+func (z *zipResponse) Write(files []scaffold.File) error {
+	// Nothing may land outside the root folder of whoever extracts it.
+	for _, f := range files {
+		if !fs.ValidPath(f.Path) || !fs.ValidPath(path.Join(z.name, f.Path)) {
+			return fmt.Errorf("refusing to archive %s/%s", z.name, f.Path)
+		}
+	}
+	z.started = true
+	z.w.Header().Set("Content-Type", "application/zip")
+	z.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", z.name+".zip"))
+	return scaffold.Zip(z.w, z.name).Write(files)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
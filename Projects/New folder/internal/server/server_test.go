@@ -0,0 +1,129 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gen-code/internal/scaffold"
+)
+
+const request = `{"app_name":"My Api","language":"Go","framework":"Gin","project_type":"backend","complexity":"minimal"}`
+
+func post(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/generate", strings.NewReader(body)))
+	return rec
+}
+
+func TestOptions(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Options{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/options", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var got struct{ Languages []LanguageOption }
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range got.Languages {
+		for _, fw := range l.Frameworks {
+			if fw.Name == "Gin" && len(fw.ProjectTypes) > 0 && len(fw.Complexities) > 0 && len(fw.Complexities[0].Addons) > 0 {
+				return
+			}
+		}
+	}
+	t.Fatalf("Gin with its options not listed: %s", rec.Body)
+}
+
+func TestGenerate(t *testing.T) {
+	rec := post(t, New(Options{}).Handler(), request)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("status = %d, type = %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="my-api.zip"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "my-api/") || strings.Contains(f.Name, "..") {
+			t.Errorf("entry %s is outside my-api/", f.Name)
+		}
+	}
+	f, err := zr.Open("my-api/main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	main, _ := io.ReadAll(f)
+	if !strings.Contains(string(main), "package main") {
+		t.Errorf("main.go = %q", main)
+	}
+}
+
+func TestGenerateRejects(t *testing.T) {
+	h := New(Options{MaxBodyBytes: 256}).Handler()
+	for _, tc := range []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"app_name":`, http.StatusBadRequest, "not a valid answers object"},
+		{`{"app_nme":"x"}`, http.StatusBadRequest, "unknown field"},
+		{`{"app_name":"x","openapi":"/etc/passwd"}`, http.StatusBadRequest, "cannot be used over HTTP"},
+		{`{"app_name":"x","language":"Go","framework":"Flask"}`, http.StatusUnprocessableEntity, `unknown framework \"Flask\"`},
+		{`{"app_name":"` + strings.Repeat("x", 300) + `"}`, http.StatusRequestEntityTooLarge, "larger than 256 bytes"},
+		{`{"app_name":"x","language":"Go","framework":"Gin","project_type":"cli","complexity":"minimal","module_path":".."}`, http.StatusBadRequest, "Module Path"},
+		{`{"app_name":"x","language":"Go","framework":"Gin","project_type":"cli","complexity":"minimal","module_path":"a/../b"}`, http.StatusBadRequest, "Module Path"},
+		{`{"app_name":"x","language":"Go","framework":"Gin","project_type":"cli","complexity":"minimal","module_path":"a\"b"}`, http.StatusBadRequest, "Module Path"},
+	} {
+		rec := post(t, h, tc.body)
+		if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s: got %d %s, want %d with %q", tc.body, rec.Code, rec.Body, tc.status, tc.want)
+		}
+	}
+}
+
+func TestZipResponseRefusesEscapes(t *testing.T) {
+	for _, tc := range []struct{ root, file string }{
+		{"..", "go.mod"},
+		{"my-api", "../go.mod"},
+		{"my-api", "/etc/passwd"},
+	} {
+		rec := httptest.NewRecorder()
+		z := &zipResponse{w: rec, name: tc.root}
+		if err := z.Write([]scaffold.File{{Path: tc.file}}); err == nil || z.started {
+			t.Errorf("%s/%s was archived", tc.root, tc.file)
+		}
+	}
+}
+
+func TestGenerateLimitsConcurrency(t *testing.T) {
+	s := New(Options{MaxConcurrent: 1})
+	s.slots <- struct{}{}
+	if rec := post(t, s.Handler(), request); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	<-s.slots
+
+	// Within the limit, generations run side by side.
+	s = New(Options{MaxConcurrent: 4})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			if rec := post(t, s.Handler(), request); rec.Code != http.StatusOK {
+				t.Errorf("status = %d: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	keywordStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF"))
+	stringStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00"))
+	commentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	numberStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF"))
+)
+
+// syntax is just enough of a language to colour it: comments, strings,
+// numbers and keywords. Block comments (and Python docstrings) may span
+// lines; everything else is matched within a line.
+type syntax struct {
+	line            string
+	blockOpen       string
+	blockClose      string
+	blockIsString   bool
+	quotes          string
+	keywords        map[string]bool
+	keywordsAnyCase bool
+}
+
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+var (
+	goSyntax = syntax{line: "//", blockOpen: "/*", blockClose: "*/", quotes: "\"`'", keywords: words(`
+		break case chan const continue default defer else fallthrough for func go goto if import
+		interface map package range return select struct switch type var nil true false`)}
+	jsSyntax = syntax{line: "//", blockOpen: "/*", blockClose: "*/", quotes: "\"`'", keywords: words(`
+		async await break case catch class const continue default delete else export extends
+		finally for from function if import in instanceof let new of return static super switch
+		this throw try typeof var void while yield null undefined true false`)}
+	pySyntax = syntax{line: "#", blockOpen: `"""`, blockClose: `"""`, blockIsString: true, quotes: `"'`, keywords: words(`
+		and as assert async await break class continue def del elif else except finally for from
+		global if import in is lambda nonlocal not or pass raise return try while with yield
+		None True False`)}
+	sqlSyntax = syntax{line: "--", quotes: `'"`, keywordsAnyCase: true, keywords: words(`
+		CREATE TABLE IF NOT EXISTS DROP PRIMARY KEY REFERENCES NULL DEFAULT INTEGER TEXT REAL
+		BOOLEAN TIMESTAMP SERIAL BIGSERIAL BIGINT VARCHAR DOUBLE PRECISION INDEX ON UNIQUE`)}
+	cssSyntax   = syntax{blockOpen: "/*", blockClose: "*/", quotes: `"'`}
+	hashSyntax  = syntax{line: "#", quotes: `"'`}
+	jsonSyntax  = syntax{quotes: `"`}
+	dockerWords = words(`FROM AS RUN COPY ADD WORKDIR ENV ARG EXPOSE CMD ENTRYPOINT USER LABEL VOLUME`)
+)
+
+// syntaxFor picks the syntax by file name; ok is false for files shown as
+// plain text.
+func syntaxFor(p string) (syntax, bool) {
+	base := path.Base(p)
+	switch base {
+	case "Dockerfile":
+		s := hashSyntax
+		s.keywords = dockerWords
+		return s, true
+	case "Makefile", ".gitignore", ".editorconfig", ".dockerignore", ".env", ".env.example", "requirements.txt", "requirements-dev.txt":
+		return hashSyntax, true
+	}
+	switch path.Ext(base) {
+	case ".go":
+		return goSyntax, true
+	case ".js", ".cjs", ".mjs", ".ts":
+		return jsSyntax, true
+	case ".py":
+		return pySyntax, true
+	case ".sql":
+		return sqlSyntax, true
+	case ".css":
+		return cssSyntax, true
+	case ".sh", ".yml", ".yaml", ".toml", ".cfg", ".ini":
+		return hashSyntax, true
+	case ".json", ".html":
+		return jsonSyntax, true
+	}
+	return syntax{}, false
+}
+
+// highlight colours content for the preview pane, line by line.
+func highlight(p, content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	sx, ok := syntaxFor(p)
+	if !ok {
+		return lines
+	}
+	inBlock := false
+	for i, l := range lines {
+		lines[i] = sx.colour(l, &inBlock)
+	}
+	return lines
+}
+
+func (sx syntax) colour(s string, inBlock *bool) string {
+	block := commentStyle
+	if sx.blockIsString {
+		block = stringStyle
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case *inBlock:
+			end := strings.Index(rest, sx.blockClose)
+			if end < 0 {
+				b.WriteString(block.Render(rest))
+				return b.String()
+			}
+			end += len(sx.blockClose)
+			b.WriteString(block.Render(rest[:end]))
+			*inBlock = false
+			i += end
+		case sx.line != "" && strings.HasPrefix(rest, sx.line):
+			b.WriteString(commentStyle.Render(rest))
+			return b.String()
+		case sx.blockOpen != "" && strings.HasPrefix(rest, sx.blockOpen):
+			b.WriteString(block.Render(sx.blockOpen))
+			*inBlock = true
+			i += len(sx.blockOpen)
+		case strings.IndexByte(sx.quotes, s[i]) >= 0:
+			j := i + 1
+			for j < len(s) && s[j] != s[i] {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			b.WriteString(stringStyle.Render(s[i:j]))
+			i = j
+		case isDigit(s[i]) && (i == 0 || !isIdent(s[i-1])):
+			j := i
+			for j < len(s) && (isIdent(s[j]) || s[j] == '.') {
+				j++
+			}
+			b.WriteString(numberStyle.Render(s[i:j]))
+			i = j
+		case isIdent(s[i]):
+			j := i
+			for j < len(s) && isIdent(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			if sx.keywordsAnyCase {
+				word = strings.ToUpper(word)
+			}
+			if sx.keywords[word] {
+				b.WriteString(keywordStyle.Render(s[i:j]))
+			} else {
+				b.WriteString(s[i:j])
+			}
+			i = j
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdent(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
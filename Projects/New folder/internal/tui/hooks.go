@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hookLogLines is how much of a hook's output the progress view keeps.
+const hookLogLines = 8
+
+// hookRun is one post-generation hook and how it went.
+type hookRun struct {
+	hook    matrix.Hook
+	log     []string
+	err     error
+	elapsed time.Duration
+	done    bool
+}
+
+func (run hookRun) failed() bool {
+	return run.err != nil && !errors.Is(run.err, scaffold.ErrHookSkipped)
+}
+
+// hookLineMsg is a line of output from the running hook.
+type hookLineMsg string
+
+type hookDoneMsg struct {
+	err     error
+	elapsed time.Duration
+}
+
+// startHooks runs the project's hooks one by one once the files are
+// written, or reports false when there are none to run.
+func (m *Model) startHooks() (tea.Cmd, bool) {
+	if m.skipHooks {
+		return nil, false
+	}
+	hooks := m.registry.Hooks(m.answers().Spec())
+	if len(hooks) == 0 {
+		return nil, false
+	}
+	m.hooks = make([]hookRun, len(hooks))
+	for i, h := range hooks {
+		m.hooks[i].hook = h
+	}
+	m.hookIndex = 0
+	m.hookCtx, m.stopHooks = context.WithCancel(context.Background())
+	return m.runHook(), true
+}
+
+// runHook starts the current hook. Its output arrives as hookLineMsgs on
+// m.hookMsgs, followed by a hookDoneMsg.
+func (m *Model) runHook() tea.Cmd {
+	ch := make(chan tea.Msg)
+	m.hookMsgs = ch
+	ctx, h, dir := m.hookCtx, m.hooks[m.hookIndex].hook, m.outputPath
+	return func() tea.Msg {
+		go func() {
+			w := &lineWriter{ctx: ctx, ch: ch}
+			start := time.Now()
+			err := scaffold.RunHook(ctx, dir, h, w)
+			w.flush()
+			select {
+			case ch <- hookDoneMsg{err: err, elapsed: time.Since(start)}:
+			case <-ctx.Done():
+			}
+		}()
+		return <-ch
+	}
+}
+
+// waitHook delivers the next message from the running hook.
+func (m Model) waitHook() tea.Cmd {
+	ch := m.hookMsgs
+	return func() tea.Msg { return <-ch }
+}
+
+// hookLine records a line of output from the running hook.
+func (m *Model) hookLine(line string) tea.Cmd {
+	run := &m.hooks[m.hookIndex]
+	run.log = append(run.log, line)
+	if len(run.log) > hookLogLines {
+		run.log = run.log[len(run.log)-hookLogLines:]
+	}
+	return m.waitHook()
+}
+
+// hookDone records how the running hook went and starts the next one, or
+// finishes after the last.
+func (m *Model) hookDone(msg hookDoneMsg) tea.Cmd {
+	run := &m.hooks[m.hookIndex]
+	run.err, run.elapsed, run.done = msg.err, msg.elapsed, true
+	if m.hookIndex++; m.hookIndex < len(m.hooks) {
+		return m.runHook()
+	}
+	m.stopHooks()
+	m.state = stateDone
+	return nil
+}
+
+func (m Model) hooksFailed() int {
+	n := 0
+	for _, run := range m.hooks {
+		if run.failed() {
+			n++
+		}
+	}
+	return n
+}
+
+// renderProgress shows the files written so far and the hooks: done,
+// running with the tail of its output, or still to come.
+func (m Model) renderProgress() string {
+	if m.report == nil {
+		return "Writing files...\n"
+	}
+	s := okStyle.Render("✓") + " Wrote files: " + m.report.Summary() + "\n"
+	for i, run := range m.hooks {
+		switch {
+		case run.done:
+			s += renderHook(run) + "\n"
+		case i == m.hookIndex:
+			s += lipgloss.NewStyle().Foreground(lipgloss.Color("#00D7FF")).Render("…") + " " + run.hook.Name + " (running)\n"
+			for _, l := range run.log {
+				s += commentStyle.Render("    "+l) + "\n"
+			}
+		default:
+			s += commentStyle.Render("· "+run.hook.Name) + "\n"
+		}
+	}
+	return s
+}
+
+// renderHooks lists how every hook went, with the output of failed ones.
+func (m Model) renderHooks() string {
+	if m.skipHooks {
+		return "Post-generation hooks skipped.\n\n"
+	}
+	if len(m.hooks) == 0 {
+		return ""
+	}
+	s := "Hooks:\n"
+	for _, run := range m.hooks {
+		s += "  " + renderHook(run) + "\n"
+		if run.failed() {
+			for _, l := range run.log {
+				s += commentStyle.Render("      "+l) + "\n"
+			}
+		}
+	}
+	if n := m.hooksFailed(); n > 0 {
+		s += fmt.Sprintf("%d of %d hook(s) failed; the project itself is complete. Run them again by hand once the cause is fixed.\n", n, len(m.hooks))
+	}
+	return s + "\n"
+}
+
+func renderHook(run hookRun) string {
+	if !run.done {
+		return commentStyle.Render("- " + run.hook.Name + " (not run)")
+	}
+	if errors.Is(run.err, scaffold.ErrHookSkipped) {
+		return commentStyle.Render("- " + run.err.Error())
+	}
+	if run.err != nil {
+		return errStyle.Render("✗") + " " + run.err.Error()
+	}
+	return okStyle.Render("✓") + fmt.Sprintf(" %s (%s)", run.hook.Name, run.elapsed.Round(100*time.Millisecond))
+}
+
+var (
+	okStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
+	errStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+)
+
+// lineWriter turns a hook's output into hookLineMsgs, one per line. It
+// gives up once the hooks are stopped, so a quitting wizard never blocks
+// the command.
+type lineWriter struct {
+	ctx     context.Context
+	ch      chan<- tea.Msg
+	partial string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	text := w.partial + strings.ReplaceAll(string(p), "\r", "\n")
+	lines := strings.Split(text, "\n")
+	w.partial = lines[len(lines)-1]
+	for _, l := range lines[:len(lines)-1] {
+		w.send(l)
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	if w.partial != "" {
+		w.send(w.partial)
+		w.partial = ""
+	}
+}
+
+func (w *lineWriter) send(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	select {
+	case w.ch <- hookLineMsg(line):
+	case <-w.ctx.Done():
+	}
+}
@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/scaffold"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHooksStreamIntoProgress(t *testing.T) {
+	reg := matrix.NewRegistry()
+	err := reg.Register(matrix.FrameworkInfo{Name: "Hooked", Language: matrix.Go, Pack: "hooked",
+		ProjectTypes: []matrix.ProjectType{matrix.CLI}, Complexities: []matrix.Complexity{matrix.Minimal},
+		Hooks: []matrix.Hook{
+			{Name: "greet", Run: []string{"sh", "-c", "echo hello; echo world"}},
+			{Name: "break", Run: []string{"sh", "-c", "echo oops >&2; exit 3"}},
+			{Name: "again", Run: []string{"sh", "-c", "echo again"}, Unless: []string{"true"}},
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := InitialModel(Options{Registry: reg})
+	m.appName, m.selectedLang, m.selectedFW = "demo", matrix.Go, "Hooked"
+	m.env, m.comp = matrix.CLI, matrix.Minimal
+	m.outputPath, m.state = t.TempDir(), stateScaffolding
+
+	next, cmd := m.Update(scaffoldingMsg{report: &scaffold.Report{}})
+	m = next.(Model)
+	var lines []string
+	for cmd != nil {
+		msg := cmd()
+		if line, ok := msg.(hookLineMsg); ok {
+			lines = append(lines, string(line))
+			if !strings.Contains(m.View(), "greet (running)") && !strings.Contains(m.View(), "break (running)") {
+				t.Errorf("progress does not show the running hook:\n%s", m.View())
+			}
+		}
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(Model)
+	}
+
+	if m.state != stateDone || strings.Join(lines, ",") != "hello,world,oops" {
+		t.Fatalf("state %v, output %q", m.state, lines)
+	}
+	view := m.View()
+	if !strings.Contains(view, "✓ greet") || !strings.Contains(view, "break failed: exit status 3") || !strings.Contains(view, "again skipped: true succeeded") || !strings.Contains(view, "1 of 3 hook(s) failed") {
+		t.Errorf("done screen does not report each hook:\n%s", view)
+	}
+}
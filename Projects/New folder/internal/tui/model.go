@@ -1,11 +1,21 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"gen-code/internal/answers"
 	"gen-code/internal/matrix"
+	"gen-code/internal/presets"
 	"gen-code/internal/scaffold"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -13,14 +23,22 @@ import (
 type state int
 
 const (
-	stateAppName state = iota
+	stateStart state = iota
+	stateAppName
 	stateLanguageSelection
 	stateFrameworkSelection
 	stateEnvSelection
 	stateComplexitySelection
+	stateAddonSelection
+	statePrompt
 	statePath
+	stateReview
+	stateConfirm
+	statePolicy
 	stateScaffolding
 	stateDone
+	stateFailed
+	statePresetName
 )
 
 const asciiHeader = `
@@ -45,26 +63,108 @@ type Model struct {
 	selectedFW   matrix.Framework
 	outputPath   string
 
-	env  matrix.ProjectType
-	comp matrix.Complexity
+	env    matrix.ProjectType
+	comp   matrix.Complexity
+	addons []matrix.Addon
+	port   int
+
+	// modulePath and vars answer the stack's extra prompts; promptIndex is
+	// the one being asked.
+	modulePath  string
+	vars        map[string]string
+	promptIndex int
+
+	// fromPreset is set when a preset or recent project supplied the stack,
+	// so the wizard only asks for the name and the path.
+	fromPreset bool
+
+	// inputErr explains why the text just entered was not accepted.
+	inputErr string
+
+	// review holds the rendered project while its files are reviewed;
+	// excluded are the paths unticked there.
+	review       []scaffold.File
+	excluded     []string
+	reviewCursor int
+	preview      viewport.Model
+
+	report    *scaffold.Report
+	showDiffs bool
+	err       error
+
+	// hooks run one at a time once the files are written; hookMsgs carries
+	// the output of the running one, and stopHooks cancels them.
+	skipHooks bool
+	hooks     []hookRun
+	hookIndex int
+	hookMsgs  chan tea.Msg
+	hookCtx   context.Context
+	stopHooks context.CancelFunc
+
+	notice   string
+	warnings []string
+	registry *matrix.Registry
+	store    *presets.Store
+}
+
+// menuItem is one selectable line of a wizard step.
+type menuItem struct {
+	value       string
+	description string
+}
+
+// Options carries start-up information from main into the wizard.
+type Options struct {
+	// Warnings are shown on the first screen, e.g. template packs that
+	// could not be loaded.
+	Warnings []string
+
+	// Registry supplies every menu; nil means matrix.DefaultRegistry().
+	Registry *matrix.Registry
+
+	// Store holds saved presets and recent projects. When it has any, they
+	// are offered on a start screen; generated projects are recorded in it.
+	Store *presets.Store
+
+	// SkipHooks leaves out the post-generation hooks, e.g. when offline.
+	SkipHooks bool
 }
 
 type scaffoldingMsg struct {
-	err error
+	report *scaffold.Report
+	err    error
 }
 
-func InitialModel() Model {
+func InitialModel(opts Options) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Enter app name..."
 	ti.Focus()
 	ti.CharLimit = 156
 	ti.Width = 30
 
-	return Model{
+	if opts.Registry == nil {
+		opts.Registry = matrix.DefaultRegistry()
+	}
+
+	m := Model{
 		state:     stateAppName,
 		textInput: ti,
 		choice:    0,
+		preview:   viewport.New(0, 0),
+		warnings:  opts.Warnings,
+		registry:  opts.Registry,
+		store:     opts.Store,
+		skipHooks: opts.SkipHooks,
+	}
+	if m.hasStart() {
+		m.state = stateStart
 	}
+	return m
+}
+
+// hasStart reports whether there is anything to offer besides a new project.
+func (m Model) hasStart() bool {
+	return m.store != nil && (len(m.store.Presets) > 0 || len(m.store.Recent) > 0)
 }
 
 func (m Model) Init() tea.Cmd {
@@ -78,22 +178,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c":
+			if m.stopHooks != nil {
+				m.stopHooks()
+			}
 			m.quitting = true
 			return m, tea.Quit
+		case "esc":
+			if m.state != stateDone && m.state != stateFailed {
+				m.back()
+				return m, nil
+			}
+		}
+		if m.state == stateScaffolding {
+			return m, nil
 		}
 
-		if m.state == stateAppName || m.state == statePath {
+		if m.state == stateAppName || m.state == statePath || m.state == statePresetName || m.state == statePrompt && promptIsText(m.prompts()[m.promptIndex]) {
 			if msg.String() == "enter" {
-				if m.state == stateAppName {
-					m.appName = m.textInput.Value()
+				value := strings.TrimSpace(m.textInput.Value())
+				if m.state == statePrompt {
+					m.answerPrompt(value)
+					return m, nil
+				}
+				if m.state == statePresetName {
+					m.savePreset(value)
+					return m, nil
+				}
+				if m.state == stateAppName && m.fromPreset {
+					if err := matrix.ValidateAppName(m.selectedLang, value); err != nil {
+						m.inputErr = err.Error()
+						return m, nil
+					}
+					m.appName = value
+					m.inputErr = ""
+					m.askPath()
+				} else if m.state == stateAppName {
+					// The language-specific rules are checked once the
+					// language is known.
+					if err := matrix.ValidateAppName("", value); err != nil {
+						m.inputErr = err.Error()
+						return m, nil
+					}
+					m.appName = value
+					m.inputErr = ""
 					m.state = stateLanguageSelection
-					m.choice = 0
+					m.choose(string(m.selectedLang))
 				} else {
-					m.outputPath = m.textInput.Value()
-					m.state = stateScaffolding
-					return m, func() tea.Msg {
-						err := scaffold.Scaffold(m.appName, m.selectedLang, m.selectedFW, m.env, m.comp, m.outputPath)
-						return scaffoldingMsg{err: err}
+					if err := scaffold.CheckOutputDir(value); err != nil {
+						m.inputErr = err.Error()
+						return m, nil
+					}
+					m.outputPath = value
+					m.inputErr = ""
+					if err := m.startReview(); err != nil {
+						m.inputErr = err.Error()
 					}
 				}
 				return m, nil
@@ -102,6 +240,49 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		if m.state == stateReview {
+			switch msg.String() {
+			case "up", "k":
+				m.moveReview(-1)
+			case "down", "j":
+				m.moveReview(1)
+			case " ":
+				m.toggleReview()
+				m.showPreview()
+			case "a":
+				m.toggleAllReview()
+				m.showPreview()
+			case "pgdown", "ctrl+d":
+				m.preview.HalfPageDown()
+			case "pgup", "ctrl+u":
+				m.preview.HalfPageUp()
+			case "enter":
+				if len(m.excluded) == len(m.review) {
+					m.inputErr = "tick at least one file"
+					return m, nil
+				}
+				m.inputErr = ""
+				m.state = stateConfirm
+			case "q":
+				m.quitting = true
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		if m.state == stateConfirm {
+			switch msg.String() {
+			case "enter", "y":
+				m.state = stateScaffolding
+				// Abort is the safe first attempt: on a conflict nothing is
+				// written and the user gets to pick a policy.
+				return m, m.scaffold(scaffold.PolicyAbort)
+			case "n":
+				m.back()
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "q":
 			m.quitting = true
@@ -111,71 +292,421 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.choice--
 			}
 		case "down", "j":
-			max := 2
-			if m.state == stateFrameworkSelection {
-				switch m.selectedLang {
-				case matrix.Go:
-					max = 2
-				case matrix.JS:
-					max = 1
-				case matrix.Python:
-					max = 2
-				}
-			}
-			if m.choice < max {
+			if m.choice < len(m.menu())-1 {
 				m.choice++
 			}
+		case " ":
+			if m.state == stateAddonSelection {
+				m.toggleAddon()
+			}
 		case "enter":
+			if m.state == stateAddonSelection {
+				m.askPrompts()
+				return m, nil
+			}
+
+			items := m.menu()
+			if m.choice >= len(items) {
+				return m, nil
+			}
+			value := items[m.choice].value
+
 			switch m.state {
+			case stateStart:
+				m.start(m.choice)
 			case stateLanguageSelection:
-				m.selectedLang = []matrix.Language{matrix.Go, matrix.JS, matrix.Python}[m.choice]
+				m.selectedLang = matrix.Language(value)
+				if err := matrix.ValidateAppName(m.selectedLang, m.appName); err != nil {
+					m.askName()
+					m.inputErr = err.Error()
+					break
+				}
 				m.state = stateFrameworkSelection
-				m.choice = 0
+				m.choose(string(m.selectedFW))
 			case stateFrameworkSelection:
-				switch m.selectedLang {
-				case matrix.Go:
-					m.selectedFW = []matrix.Framework{matrix.Gin, matrix.Echo, matrix.Fiber}[m.choice]
-				case matrix.JS:
-					m.selectedFW = []matrix.Framework{matrix.Express, matrix.Fastify}[m.choice]
-				case matrix.Python:
-					m.selectedFW = []matrix.Framework{matrix.Flask, matrix.FastAPI, matrix.Django}[m.choice]
-				}
+				m.selectedFW = matrix.Framework(value)
 				m.state = stateEnvSelection
-				m.choice = 0
+				m.choose(string(m.env))
 			case stateEnvSelection:
-				m.env = []matrix.ProjectType{matrix.WebApp, matrix.CLI, matrix.Backend}[m.choice]
+				m.env = matrix.ProjectType(value)
 				m.state = stateComplexitySelection
-				m.choice = 0
+				m.choose(string(m.comp))
+			case statePolicy:
+				policy := scaffold.Policy(value)
+				if policy == scaffold.PolicyAbort {
+					m.quitting = true
+					return m, tea.Quit
+				}
+				m.state, m.report = stateScaffolding, nil
+				return m, m.scaffold(policy)
+			case statePrompt:
+				m.answerPrompt(value)
 			case stateComplexitySelection:
-				m.comp = []matrix.Complexity{matrix.Minimal, matrix.Standard, matrix.Enterprise}[m.choice]
-				m.state = statePath
-				m.textInput.Placeholder = "Enter output path..."
-				m.textInput.SetValue(".")
+				m.comp = matrix.Complexity(value)
+				// Keep earlier picks that are still on offer.
+				offered := m.offeredAddons()
+				m.addons = slices.DeleteFunc(m.addons, func(a matrix.Addon) bool {
+					return !slices.ContainsFunc(offered, func(o matrix.AddonInfo) bool { return o.Name == a })
+				})
+				if len(offered) == 0 {
+					m.askPrompts()
+					break
+				}
+				m.state = stateAddonSelection
+				m.choice = 0
+			}
+		case "d":
+			if m.state == statePolicy {
+				m.showDiffs = !m.showDiffs
+			}
+		case "s":
+			if m.state == stateDone {
+				// Keep the file with the project rather than wherever gen-code was started.
+				dir := m.outputPath
+				if scaffold.ArchiveFormat(dir) != "" {
+					dir = filepath.Dir(dir)
+				}
+				path := filepath.Join(dir, m.appName+".answers.yaml")
+				if abs, err := filepath.Abs(path); err == nil {
+					path = abs
+				}
+				if err := m.answers().Save(path); err != nil {
+					m.notice = "Could not save answers: " + err.Error()
+				} else {
+					m.notice = "Answers saved to " + path + " (replay with: gen-code -answers " + path + ")"
+				}
+				return m, nil
+			}
+		case "p":
+			if m.state == stateDone && m.store != nil {
+				m.state = statePresetName
+				m.notice = ""
+				m.textInput.Placeholder = "Enter preset name..."
+				m.textInput.SetValue("")
 				m.textInput.Focus()
+				return m, nil
+			}
+			if m.state == stateDone || m.state == stateFailed {
+				m.quitting = true
+				return m, tea.Quit
 			}
 		default:
-			if m.state == stateDone {
+			if m.state == stateDone || m.state == stateFailed {
 				m.quitting = true
 				return m, tea.Quit
 			}
 		}
 
 	case scaffoldingMsg:
+		m.report = msg.report
+		var conflict *scaffold.ConflictError
+		if errors.As(msg.err, &conflict) {
+			m.state = statePolicy
+			m.choice = 0
+			return m, nil
+		}
 		if msg.err != nil {
-			fmt.Printf("Error during scaffolding: %v\n", msg.err)
-			return m, tea.Quit
+			m.err = msg.err
+			m.state = stateFailed
+			return m, nil
+		}
+		if m.store != nil {
+			m.store.Remember(m.answers(), time.Now())
+			if err := m.store.Save(); err != nil {
+				m.notice = "Could not record the project in the history: " + err.Error()
+			}
+		}
+		if cmd, ok := m.startHooks(); ok {
+			return m, cmd
 		}
 		m.state = stateDone
 		return m, nil
 
+	case hookLineMsg:
+		return m, m.hookLine(string(msg))
+
+	case hookDoneMsg:
+		return m, m.hookDone(msg)
+
 	case tea.WindowSizeMsg:
 		m.windowWidth = msg.Width
 		m.windowHeight = msg.Height
+		m.resizePreview()
 	}
 
 	return m, nil
 }
 
+func (m *Model) askName() {
+	m.state = stateAppName
+	m.textInput.Placeholder = "Enter app name..."
+	m.textInput.SetValue(m.appName)
+	m.textInput.Focus()
+}
+
+func (m *Model) askPath() {
+	m.state = statePath
+	m.textInput.Placeholder = "Enter output path..."
+	if m.outputPath == "" {
+		m.outputPath = "."
+	}
+	m.textInput.SetValue(m.outputPath)
+	m.textInput.Focus()
+}
+
+// start acts on the start screen entry at index i: a new project, then the
+// presets, then the recent projects.
+func (m *Model) start(i int) {
+	m.fromPreset = false
+	if i == 0 {
+		m.askName()
+		return
+	}
+	i--
+
+	var a answers.Answers
+	if i < len(m.store.Presets) {
+		p, err := m.store.Presets[i].Validate()
+		if err != nil {
+			m.notice = err.Error()
+			return
+		}
+		a = p.Apply(answers.Answers{AppName: m.appName, Output: m.outputPath})
+	} else {
+		var err error
+		a, err = m.store.Recent[i-len(m.store.Presets)].Answers.Validate()
+		if err != nil {
+			m.notice = err.Error()
+			return
+		}
+	}
+
+	m.notice = ""
+	m.appName = a.AppName
+	m.outputPath = a.Output
+	m.selectedLang = a.Language
+	m.selectedFW = a.Framework
+	m.env = a.ProjectType
+	m.comp = a.Complexity
+	m.port = a.Port
+	m.modulePath = a.ModulePath
+	m.vars = a.Vars
+	m.addons = a.Addons
+	m.fromPreset = true
+	m.askName()
+}
+
+func (m *Model) savePreset(name string) {
+	if err := m.store.SavePreset(presets.FromAnswers(name, m.answers())); err != nil {
+		m.inputErr = err.Error()
+		return
+	}
+	m.inputErr = ""
+	m.state = stateDone
+	if err := m.store.Save(); err != nil {
+		m.notice = "Could not save preset: " + err.Error()
+	} else {
+		m.notice = "Preset " + name + " saved to " + m.store.Path() + " (use it with: gen-code -preset " + name + ")"
+	}
+}
+
+// back returns to the previous step with the earlier answer highlighted.
+func (m *Model) back() {
+	m.inputErr = ""
+	switch m.state {
+	case stateAppName:
+		if m.hasStart() {
+			m.state = stateStart
+			m.choice = 0
+		}
+	case stateLanguageSelection:
+		m.askName()
+	case stateFrameworkSelection:
+		m.state = stateLanguageSelection
+		m.choose(string(m.selectedLang))
+	case stateEnvSelection:
+		m.state = stateFrameworkSelection
+		m.choose(string(m.selectedFW))
+	case stateComplexitySelection:
+		m.state = stateEnvSelection
+		m.choose(string(m.env))
+	case stateAddonSelection:
+		m.state = stateComplexitySelection
+		m.choose(string(m.comp))
+	case statePrompt:
+		m.promptsBack()
+	case statePath:
+		if m.fromPreset {
+			m.askName()
+			break
+		}
+		if n := len(m.prompts()); n > 0 {
+			m.promptIndex = n - 1
+			m.showPrompt()
+			break
+		}
+		m.promptIndex = 0
+		m.promptsBack()
+	case stateReview:
+		m.askPath()
+	case stateConfirm:
+		m.state = stateReview
+	case statePolicy:
+		m.state = stateConfirm
+		m.showDiffs = false
+	case statePresetName:
+		m.state = stateDone
+	}
+}
+
+// choose moves the cursor to value in the current menu, or to the top when
+// it is not there.
+func (m *Model) choose(value string) {
+	m.choice = 0
+	for i, item := range m.menu() {
+		if item.value == value {
+			m.choice = i
+		}
+	}
+}
+
+func (m Model) offeredAddons() []matrix.AddonInfo {
+	return m.registry.Addons(m.selectedLang, m.selectedFW, m.comp)
+}
+
+// toggleAddon flips the highlighted add-on. Picking one member of a group,
+// such as a database, drops the other members.
+func (m *Model) toggleAddon() {
+	offered := m.offeredAddons()
+	if m.choice >= len(offered) {
+		return
+	}
+	picked := offered[m.choice]
+	if slices.Contains(m.addons, picked.Name) {
+		m.addons = slices.DeleteFunc(m.addons, func(a matrix.Addon) bool { return a == picked.Name })
+		return
+	}
+
+	var kept []matrix.Addon
+	for _, a := range offered {
+		if a.Name == picked.Name || (slices.Contains(m.addons, a.Name) && (a.Group == "" || a.Group != picked.Group)) {
+			kept = append(kept, a.Name)
+		}
+	}
+	m.addons = kept
+}
+
+func (m Model) scaffold(policy scaffold.Policy) tea.Cmd {
+	spec := m.answers().Spec()
+	opts := scaffold.Options{OutputDir: m.outputPath, Policy: policy, Exclude: m.excluded}
+	return func() tea.Msg {
+		report, err := scaffold.Scaffold(spec, opts)
+		return scaffoldingMsg{report: report, err: err}
+	}
+}
+
+// menu lists the options for the current selection step. Everything comes
+// from the registry, so packs and compatibility rules apply automatically.
+func (m Model) menu() []menuItem {
+	var items []menuItem
+
+	switch m.state {
+	case stateStart:
+		items = append(items, menuItem{"New project", "answer every question"})
+		for _, p := range m.store.Presets {
+			items = append(items, menuItem{"Preset: " + p.Name, p.Summary()})
+		}
+		for _, r := range m.store.Recent {
+			desc := r.Answers.Output + ", " + r.GeneratedAt.Format("2006-01-02 15:04")
+			items = append(items, menuItem{"Recent: " + r.Answers.AppName, desc})
+		}
+	case stateLanguageSelection:
+		for _, l := range m.registry.Languages() {
+			items = append(items, menuItem{string(l.Name), l.Description})
+		}
+	case stateFrameworkSelection:
+		for _, fw := range m.registry.Frameworks(m.selectedLang) {
+			desc := fw.Description
+			if fw.Pack != "" {
+				desc += " [pack: " + fw.Pack + "]"
+			}
+			items = append(items, menuItem{string(fw.Name), desc})
+		}
+	case stateEnvSelection:
+		for _, pt := range m.registry.ProjectTypes(m.selectedLang, m.selectedFW) {
+			items = append(items, menuItem{string(pt.Name), pt.Description})
+		}
+	case stateComplexitySelection:
+		for _, c := range m.registry.Complexities(m.selectedLang, m.selectedFW) {
+			items = append(items, menuItem{string(c.Name), c.Description})
+		}
+	case stateAddonSelection:
+		for _, a := range m.offeredAddons() {
+			box := "[ ] "
+			if slices.Contains(m.addons, a.Name) {
+				box = "[x] "
+			}
+			items = append(items, menuItem{box + string(a.Name), a.Description})
+		}
+	case statePrompt:
+		p := m.prompts()[m.promptIndex]
+		def := p.DefaultFor(m.answers().Spec())
+		choices := p.Choices
+		if p.Kind == matrix.PromptBool {
+			choices = []string{"yes", "no"}
+			def = yesNo(def == "true")
+		}
+		for _, c := range choices {
+			desc := ""
+			if c == def {
+				desc = "default"
+			}
+			items = append(items, menuItem{c, desc})
+		}
+	case statePolicy:
+		for _, p := range scaffold.Policies {
+			items = append(items, menuItem{string(p), p.Description()})
+		}
+	}
+
+	return items
+}
+
+func (m Model) renderMenu(highlight string) string {
+	var s string
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#626262"))
+	for i, item := range m.menu() {
+		cursor := " "
+		choice := item.value
+		if m.choice == i {
+			cursor = ">"
+			choice = lipgloss.NewStyle().Foreground(lipgloss.Color(highlight)).Render(choice)
+		}
+		if item.description == "" {
+			s += fmt.Sprintf("%s %s\n", cursor, choice)
+			continue
+		}
+		s += fmt.Sprintf("%s %s %s\n", cursor, choice, dim.Render("- "+item.description))
+	}
+	return s
+}
+
+func (m Model) answers() answers.Answers {
+	a := answers.Answers{
+		AppName:     m.appName,
+		Language:    m.selectedLang,
+		Framework:   m.selectedFW,
+		ProjectType: m.env,
+		Complexity:  m.comp,
+		Output:      m.outputPath,
+		ModulePath:  m.modulePath,
+		Port:        m.port,
+		Addons:      m.addons,
+	}
+	a.Vars = m.askedVars(a.Spec())
+	return a
+}
+
 func (m Model) View() string {
 	if m.quitting {
 		return "Bye!\n"
@@ -195,77 +726,196 @@ func (m Model) View() string {
 
 	var s string
 	switch m.state {
+	case stateStart:
+		s = header + "\n" + headerStyle.Render("Start From") + "\n\n"
+		s += m.renderMenu("#00D7FF")
+		if m.notice != "" {
+			s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render("! "+m.notice) + "\n"
+		}
+		s += "\n(press enter to continue)"
+		s += m.renderWarnings()
 	case stateAppName:
 		s = header + "\n" + headerStyle.Render("Step 1: Application Name") + "\n\n"
 		s += m.textInput.View() + "\n\n"
-		s += "(press enter to continue)"
+		s += m.renderInputErr()
+		if m.fromPreset {
+			s += m.summaryStack()
+		}
+		s += "(press enter to continue"
+		if m.hasStart() {
+			s += ", esc to go back"
+		}
+		s += ")"
+		if !m.hasStart() {
+			s += m.renderWarnings()
+		}
 	case stateLanguageSelection:
 		s = header + "\n" + headerStyle.Render("Step 2: Select Language") + "\n\n"
-		choices := []string{"Go", "JavaScript", "Python"}
-		for i, choice := range choices {
-			cursor := " "
-			if m.choice == i {
-				cursor = ">"
-				choice = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Render(choice)
-			}
-			s += fmt.Sprintf("%s %s\n", cursor, choice)
-		}
+		s += m.renderMenu("#FF00FF")
+		s += backHint
 	case stateFrameworkSelection:
 		s = header + "\n" + headerStyle.Render("Step 3: Select Framework") + "\n\n"
-		var choices []string
-		switch m.selectedLang {
-		case matrix.Go:
-			choices = []string{"Gin", "Echo", "Fiber"}
-		case matrix.JS:
-			choices = []string{"Express", "Fastify"}
-		case matrix.Python:
-			choices = []string{"Flask", "FastAPI", "Django"}
-		}
-		for i, choice := range choices {
-			cursor := " "
-			if m.choice == i {
-				cursor = ">"
-				choice = lipgloss.NewStyle().Foreground(lipgloss.Color("#00D7FF")).Render(choice)
-			}
-			s += fmt.Sprintf("%s %s\n", cursor, choice)
-		}
+		s += m.renderMenu("#00D7FF")
+		s += backHint
 	case stateEnvSelection:
 		s = header + "\n" + headerStyle.Render("Step 4: Select Environment") + "\n\n"
-		choices := []string{"Web Application", "CLI Tool", "Backend Service"}
-		for i, choice := range choices {
-			cursor := " "
-			if m.choice == i {
-				cursor = ">"
-				choice = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF00FF")).Render(choice)
-			}
-			s += fmt.Sprintf("%s %s\n", cursor, choice)
-		}
+		s += m.renderMenu("#FF00FF")
+		s += backHint
 	case stateComplexitySelection:
 		s = header + "\n" + headerStyle.Render("Step 5: Select Complexity") + "\n\n"
-		choices := []string{"Minimal (MVP)", "Standard (Clean Architecture)", "Enterprise (Microservices Ready)"}
-		for i, choice := range choices {
-			cursor := " "
-			if m.choice == i {
-				cursor = ">"
-				choice = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Render(choice)
-			}
-			s += fmt.Sprintf("%s %s\n", cursor, choice)
+		s += m.renderMenu("#00FFFF")
+		s += backHint
+	case stateAddonSelection:
+		s = header + "\n" + headerStyle.Render("Step 6: Select Add-ons") + "\n\n"
+		s += m.renderMenu("#FFAF00")
+		s += "\n(space to toggle, enter to continue, esc to go back)"
+	case statePrompt:
+		p := m.prompts()[m.promptIndex]
+		s = header + "\n" + headerStyle.Render(fmt.Sprintf("Step 7: %s (%d of %d)", p.Label, m.promptIndex+1, len(m.prompts()))) + "\n\n"
+		if p.Description != "" {
+			s += p.Description + "\n\n"
+		}
+		if promptIsText(p) {
+			s += m.textInput.View() + "\n\n"
+		} else {
+			s += m.renderMenu("#00FFFF") + "\n"
 		}
+		s += m.renderInputErr()
+		s += "(press enter to continue, esc to go back)"
 	case statePath:
-		s = header + "\n" + headerStyle.Render("Step 6: Output Path") + "\n\n"
+		s = header + "\n" + headerStyle.Render("Step 8: Output Path") + "\n\n"
 		s += m.textInput.View() + "\n\n"
-		s += "(press enter to generate)"
+		s += m.renderInputErr()
+		s += "(press enter to review the files, esc to go back)"
+	case stateReview:
+		// The tree and the preview get the whole screen, so the banner is
+		// left out.
+		s = headerStyle.Render(fmt.Sprintf("Review Files: %d of %d selected", len(m.review)-len(m.excluded), len(m.review))) + "\n"
+		s += m.renderReview() + "\n"
+		s += m.renderInputErr()
+		s += "(space to toggle, a for all, pgup/pgdown to scroll, enter to continue, esc to go back)"
+	case stateConfirm:
+		s = header + "\n" + headerStyle.Render("Review") + "\n\n"
+		s += m.summary()
+		s += "Output: " + m.outputPath + " " + describeOutput(m.outputPath) + "\n"
+		s += fmt.Sprintf("Files: %d of %d", len(m.review)-len(m.excluded), len(m.review))
+		if len(m.excluded) > 0 {
+			s += fmt.Sprintf(" (%d left out)", len(m.excluded))
+		}
+		s += "\n\n"
+		s += "Generate this project? (enter/y to generate, esc/n to go back, q to quit)"
+	case statePolicy:
+		s = header + "\n" + headerStyle.Render("Existing files found in "+m.outputPath) + "\n\n"
+		for _, e := range m.report.Entries {
+			if e.Diff != "" {
+				s += "  ~ " + e.Path + "\n"
+			}
+		}
+		if m.showDiffs {
+			s += "\n" + m.report.Diffs()
+		}
+		s += "\nWhat should happen to them?\n\n"
+		s += m.renderMenu("#FFAF00")
+		s += "\n(press d to toggle diffs, esc to go back)"
 	case stateScaffolding:
-		s = "Generating your masterpiece..."
+		s = header + "\n" + headerStyle.Render("Generating "+m.appName) + "\n\n"
+		s += m.renderProgress() + "\n"
+		s += "(ctrl+c to stop)"
+	case stateFailed:
+		s = header + "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render("Scaffolding failed.") + "\n\n"
+		s += m.err.Error() + "\n\n"
+		var rb *scaffold.RollbackError
+		if errors.As(m.err, &rb) {
+			s += "Rolled back:\n" + rb.Details() + "\n"
+			if len(rb.Leftovers) == 0 {
+				s += m.outputPath + " is exactly as it was before.\n\n"
+			}
+		}
+		s += "Press any key to exit."
 	case stateDone:
 		s = header + "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Render("Success! Your project has been scaffolded.") + "\n\n"
-		s += "Project: " + m.appName + "\n"
-		s += "Language: " + string(m.selectedLang) + "\n"
-		s += "Framework: " + string(m.selectedFW) + "\n"
+		s += m.summary()
 		s += "Created by: Moeed ul Hassan\n\n"
+		if m.report != nil {
+			s += m.report.Tree() + "\n" + m.report.Summary() + "\n\n"
+		}
+		s += m.renderHooks()
 		s += "Check " + m.outputPath + " for the new structure.\n"
-		s += "Press any key to exit."
+		if m.notice != "" {
+			s += m.notice + "\n"
+		}
+		if m.store != nil {
+			s += "Press s to save these answers, p to save the stack as a preset, any other key to exit."
+		} else {
+			s += "Press s to save these answers, any other key to exit."
+		}
+	case statePresetName:
+		s = header + "\n" + headerStyle.Render("Save Preset") + "\n\n"
+		s += m.summaryStack() + "\n"
+		s += m.textInput.View() + "\n\n"
+		s += m.renderInputErr()
+		s += "(press enter to save, esc to cancel)"
 	}
 
 	return lipgloss.NewStyle().Margin(1, 2).Render(s)
 }
+
+const backHint = "\n(esc to go back)"
+
+// summary lists the answers given so far, one per line.
+func (m Model) summary() string {
+	s := "Project: " + m.appName + "\n" + m.summaryStack()
+	for _, p := range m.prompts() {
+		value := m.promptValue(p)
+		if p.Kind == matrix.PromptBool {
+			value = yesNo(value == "true")
+		}
+		s += p.Label + ": " + value + "\n"
+	}
+	return s
+}
+
+// summaryStack lists the answers a preset carries.
+func (m Model) summaryStack() string {
+	s := "Language: " + string(m.selectedLang) + "\n"
+	s += "Framework: " + string(m.selectedFW) + "\n"
+	s += "Type: " + string(m.env) + "\n"
+	s += "Complexity: " + string(m.comp) + "\n"
+	if len(m.addons) > 0 {
+		names := make([]string, len(m.addons))
+		for i, a := range m.addons {
+			names[i] = string(a)
+		}
+		s += "Add-ons: " + strings.Join(names, ", ") + "\n"
+	}
+	return s
+}
+
+func (m Model) renderWarnings() string {
+	var s string
+	for _, w := range m.warnings {
+		s += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("#FFAF00")).Render("! "+w)
+	}
+	return s
+}
+
+func (m Model) renderInputErr() string {
+	if m.inputErr == "" {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")).Render("! "+m.inputErr) + "\n\n"
+}
+
+// describeOutput tells the user whether generation adds to an existing
+// directory, where conflicts are possible, or creates a new one.
+func describeOutput(dir string) string {
+	entries, err := os.ReadDir(dir)
+	switch {
+	case err != nil:
+		return "(will be created)"
+	case len(entries) == 0:
+		return "(empty directory)"
+	default:
+		return fmt.Sprintf("(existing directory with %d entries; you will be asked about any file that differs)", len(entries))
+	}
+}
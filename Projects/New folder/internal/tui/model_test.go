@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+	"gen-code/internal/presets"
+)
+
+func TestMenuCursorStaysInRange(t *testing.T) {
+	m := InitialModel(Options{})
+	m, _ = press(m, "demo", "enter")
+
+	// Fiber is the third Go framework; JavaScript only has two.
+	m, _ = press(m, "enter", "down", "down", "down", "down", "enter")
+	if m.selectedFW != matrix.Fiber {
+		t.Fatalf("picked %s, want the last Go framework", m.selectedFW)
+	}
+	m, _ = press(m, "esc", "esc", "down", "enter")
+	if m.state != stateFrameworkSelection || m.selectedLang != matrix.JS {
+		t.Fatalf("state %d with %s, want the JavaScript frameworks", m.state, m.selectedLang)
+	}
+	if m.choice != 0 {
+		t.Errorf("cursor at %d after switching language, want the top", m.choice)
+	}
+	for range 5 {
+		m, _ = press(m, "down")
+		if m.choice >= len(m.menu()) {
+			t.Fatalf("cursor at %d of %d", m.choice, len(m.menu()))
+		}
+	}
+	m, _ = press(m, "enter")
+	if m.state != stateEnvSelection || m.selectedFW != matrix.Fastify {
+		t.Fatalf("state %d with %s, want the project types for Fastify", m.state, m.selectedFW)
+	}
+
+	// Django offers two project types where the others offer three.
+	m, _ = press(m, "esc", "esc", "down", "enter", "down", "down", "enter", "down", "down", "down", "up", "down")
+	if m.selectedFW != matrix.Django || m.choice != 1 {
+		t.Fatalf("%s cursor at %d, want the last of Django's project types", m.selectedFW, m.choice)
+	}
+	m, _ = press(m, "enter")
+	if m.env != matrix.Backend {
+		t.Errorf("picked %s, want %s", m.env, matrix.Backend)
+	}
+
+	for range 5 {
+		m, _ = press(m, "up")
+	}
+	if m.choice != 0 {
+		t.Errorf("cursor at %d after moving up past the top", m.choice)
+	}
+}
+
+func TestBackKeepsAnswers(t *testing.T) {
+	store := &presets.Store{Presets: []presets.Preset{{Name: "api", Language: matrix.Go, Framework: matrix.Gin, ProjectType: matrix.Backend, Complexity: matrix.Minimal}}}
+	m := InitialModel(Options{Store: store, SkipHooks: true})
+	if m.state != stateStart {
+		t.Fatalf("state %d, want the start screen", m.state)
+	}
+
+	m, _ = press(m, "enter", "demo", "enter")
+	m, _ = press(m, "enter", "down", "enter", "down", "down", "enter", "down", "enter", "enter")
+	if m.state != statePrompt {
+		t.Fatalf("state %d, want the port prompt", m.state)
+	}
+	m.textInput.SetValue("")
+	m, _ = press(m, "9090", "enter", "enter")
+	m.textInput.SetValue(t.TempDir())
+	m, _ = press(m, "enter", "enter")
+	if m.state != stateConfirm {
+		t.Fatalf("state %d, want the confirm screen: %s", m.state, m.inputErr)
+	}
+	want := m.answers()
+
+	// Each esc goes back one screen, which shows the answer given there.
+	steps := []struct {
+		state state
+		shows string
+	}{
+		{stateReview, ""},
+		{statePath, want.Output},
+		{statePrompt, "demo"},
+		{statePrompt, "9090"},
+		{stateAddonSelection, ""},
+		{stateComplexitySelection, string(matrix.Standard)},
+		{stateEnvSelection, string(matrix.Backend)},
+		{stateFrameworkSelection, string(matrix.Echo)},
+		{stateLanguageSelection, string(matrix.Go)},
+		{stateAppName, "demo"},
+		{stateStart, ""},
+	}
+	for _, step := range steps {
+		m, _ = press(m, "esc")
+		if m.state != step.state {
+			t.Fatalf("state %d, want %d", m.state, step.state)
+		}
+		if step.shows == "" {
+			continue
+		}
+		shown := m.textInput.Value()
+		if items := m.menu(); step.state != stateAppName && step.state != statePath && step.state != statePrompt {
+			shown = items[m.choice].value
+		}
+		if shown != step.shows {
+			t.Errorf("state %d shows %q, want %q", step.state, shown, step.shows)
+		}
+	}
+	if m, _ = press(m, "esc"); m.state != stateStart {
+		t.Errorf("esc on the start screen moved to %d", m.state)
+	}
+
+	if got := m.answers(); got.AppName != want.AppName || got.Framework != want.Framework || got.ProjectType != want.ProjectType ||
+		got.Complexity != want.Complexity || got.Port != want.Port || got.Output != want.Output {
+		t.Errorf("answers after going back: %+v, want %+v", got, want)
+	}
+}
+
+func TestNameAndPathRejected(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "taken")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := InitialModel(Options{SkipHooks: true})
+	for _, name := range []string{"", "9lives", "my/app"} {
+		m.textInput.SetValue(name)
+		if m, _ = press(m, "enter"); m.state != stateAppName || m.inputErr == "" {
+			t.Errorf("app name %q accepted", name)
+		}
+	}
+	// json only clashes once Python is picked.
+	m.textInput.SetValue("")
+	m, _ = press(m, "json", "enter", "down", "down", "enter")
+	if m.state != stateAppName || !strings.Contains(m.inputErr, "standard library") {
+		t.Fatalf("state %d (%s), want json refused for Python", m.state, m.inputErr)
+	}
+
+	// A preset asks only for the name and the path, and checks both.
+	store := &presets.Store{Presets: []presets.Preset{{Name: "py", Language: matrix.Python, Framework: matrix.Flask, ProjectType: matrix.CLI, Complexity: matrix.Minimal}}}
+	m = InitialModel(Options{Store: store, SkipHooks: true})
+	m, _ = press(m, "down", "enter")
+	m.textInput.SetValue("json")
+	if m, _ = press(m, "enter"); m.state != stateAppName || m.inputErr == "" {
+		t.Fatalf("state %d, want json refused for the Python preset", m.state)
+	}
+	m.textInput.SetValue("tool")
+	m, _ = press(m, "enter")
+	for _, path := range []string{" ", file, filepath.Join(file, "sub")} {
+		m.textInput.SetValue(path)
+		if m, _ = press(m, "enter"); m.state != statePath || m.inputErr == "" {
+			t.Errorf("output path %q accepted", path)
+		}
+	}
+	m.textInput.SetValue(filepath.Join(filepath.Dir(file), "tool"))
+	if m, _ = press(m, "enter"); m.state != stateReview || m.inputErr != "" {
+		t.Errorf("state %d (%s), want the review", m.state, m.inputErr)
+	}
+}
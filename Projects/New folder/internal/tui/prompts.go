@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"maps"
+	"strconv"
+
+	"gen-code/internal/matrix"
+)
+
+// prompts lists the extra questions the chosen stack asks.
+func (m Model) prompts() []matrix.Prompt {
+	return m.registry.Prompts(m.answers().Spec())
+}
+
+// askPrompts starts on the stack's extra questions, or goes straight to the
+// output path when there are none.
+func (m *Model) askPrompts() {
+	if len(m.prompts()) == 0 {
+		m.askPath()
+		return
+	}
+	m.promptIndex = 0
+	m.showPrompt()
+}
+
+// showPrompt asks the prompt at promptIndex, starting from the earlier
+// answer or the default.
+func (m *Model) showPrompt() {
+	m.state = statePrompt
+	p := m.prompts()[m.promptIndex]
+	value := m.promptValue(p)
+	if promptIsText(p) {
+		m.textInput.Placeholder = p.Label + "..."
+		m.textInput.SetValue(value)
+		m.textInput.Focus()
+		return
+	}
+	if p.Kind == matrix.PromptBool {
+		value = yesNo(value == "true")
+	}
+	m.choose(value)
+}
+
+// promptValue is the current answer to p: the one given, or the default.
+func (m Model) promptValue(p matrix.Prompt) string {
+	switch {
+	case p.Name == matrix.VarPort && m.port != 0:
+		return strconv.Itoa(m.port)
+	case p.Name == matrix.VarModulePath && m.modulePath != "":
+		return m.modulePath
+	}
+	if value, ok := m.vars[p.Name]; ok {
+		return value
+	}
+	return p.DefaultFor(m.answers().Spec())
+}
+
+// answerPrompt records the answer to the current prompt and moves on to
+// the next one, or to the output path after the last.
+func (m *Model) answerPrompt(value string) {
+	prompts := m.prompts()
+	p := prompts[m.promptIndex]
+	value, err := p.Parse(value)
+	if err != nil {
+		m.inputErr = err.Error()
+		return
+	}
+	m.inputErr = ""
+
+	switch p.Name {
+	case matrix.VarPort:
+		m.port, _ = strconv.Atoi(value)
+	case matrix.VarModulePath:
+		m.modulePath = value
+	default:
+		if m.vars == nil {
+			m.vars = map[string]string{}
+		}
+		m.vars[p.Name] = value
+	}
+
+	if m.promptIndex++; m.promptIndex < len(prompts) {
+		m.showPrompt()
+		return
+	}
+	m.askPath()
+}
+
+// promptsBack steps back from the first prompt to the add-ons, or to the
+// complexity when no add-ons were offered.
+func (m *Model) promptsBack() {
+	if m.promptIndex > 0 {
+		m.promptIndex--
+		m.showPrompt()
+		return
+	}
+	m.state = stateAddonSelection
+	m.choice = 0
+	if len(m.offeredAddons()) == 0 {
+		m.state = stateComplexitySelection
+		m.choose(string(m.comp))
+	}
+}
+
+// askedVars keeps the answers to prompts the stack still asks; going back
+// and picking another stack must not leave stale variables behind.
+func (m Model) askedVars(spec matrix.Spec) map[string]string {
+	vars := maps.Clone(m.vars)
+	for name := range vars {
+		asked := false
+		for _, p := range m.registry.Prompts(spec) {
+			asked = asked || p.Name == name
+		}
+		if !asked {
+			delete(vars, name)
+		}
+	}
+	return vars
+}
+
+// promptIsText reports whether p is typed in rather than picked from a
+// menu.
+func promptIsText(p matrix.Prompt) bool {
+	return p.Kind == matrix.PromptString || p.Kind == matrix.PromptInt
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"testing"
+
+	"gen-code/internal/matrix"
+)
+
+func TestPromptsAnswerVars(t *testing.T) {
+	m := InitialModel(Options{})
+	m.appName, m.selectedLang, m.selectedFW = "demo", matrix.JS, matrix.Express
+	m.env, m.comp = matrix.Backend, matrix.Minimal
+	m.askPrompts()
+
+	if m.state != statePrompt || m.textInput.Value() != "3000" {
+		t.Fatalf("state %v with %q, want the port prompt with the default", m.state, m.textInput.Value())
+	}
+	m.textInput.SetValue("")
+	m, _ = press(m, "70000", "enter")
+	if m.state != statePrompt || m.inputErr == "" {
+		t.Fatalf("port 70000 was accepted")
+	}
+	m.textInput.SetValue("")
+	m, _ = press(m, "4000", "enter")
+
+	// node_version is a menu starting on its default, 20.
+	if items := m.menu(); m.choice >= len(items) || items[m.choice].value != "20" {
+		t.Fatalf("node_version menu %v at %d", items, m.choice)
+	}
+	m, _ = press(m, "j", "enter")
+	if m.state != statePath {
+		t.Fatalf("state %v after the last prompt, want the path", m.state)
+	}
+
+	a := m.answers()
+	if a.Port != 4000 || a.Vars["node_version"] != "22" {
+		t.Errorf("port %d, vars %v", a.Port, a.Vars)
+	}
+
+	m, _ = press(m, "esc")
+	if m.state != statePrompt || m.menu()[m.choice].value != "22" {
+		t.Errorf("esc from the path does not return to the last prompt with its answer")
+	}
+
+	// Another stack drops answers it does not ask for.
+	m.selectedLang, m.selectedFW = matrix.Python, matrix.Flask
+	if vars := m.answers().Vars; len(vars) != 0 {
+		t.Errorf("stale vars %v", vars)
+	}
+}
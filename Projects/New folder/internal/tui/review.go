@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gen-code/internal/scaffold"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reviewRow is one line of the file tree on the review screen: a directory,
+// which toggles everything below it, or a file.
+type reviewRow struct {
+	path  string
+	name  string
+	depth int
+	dir   bool
+}
+
+// startReview renders the project and shows its file tree. Files unticked
+// on an earlier visit stay unticked when they are still generated.
+func (m *Model) startReview() error {
+	files, err := scaffold.Preview(m.answers().Spec())
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(files, func(a, b scaffold.File) int { return strings.Compare(a.Path, b.Path) })
+
+	m.review = files
+	m.excluded = slices.DeleteFunc(m.excluded, func(p string) bool {
+		return !slices.ContainsFunc(files, func(f scaffold.File) bool { return f.Path == p })
+	})
+	m.reviewCursor = min(m.reviewCursor, len(m.reviewRows())-1)
+	m.state = stateReview
+	m.resizePreview()
+	m.showPreview()
+	return nil
+}
+
+// reviewRows lists the directories and files of the project in tree order.
+func (m Model) reviewRows() []reviewRow {
+	var rows []reviewRow
+	var prev []string
+	for _, f := range m.review {
+		parts := strings.Split(f.Path, "/")
+		dirs := parts[:len(parts)-1]
+		common := 0
+		for common < len(dirs) && common < len(prev) && dirs[common] == prev[common] {
+			common++
+		}
+		for i := common; i < len(dirs); i++ {
+			rows = append(rows, reviewRow{path: strings.Join(dirs[:i+1], "/"), name: dirs[i] + "/", depth: i, dir: true})
+		}
+		prev = dirs
+		rows = append(rows, reviewRow{path: f.Path, name: parts[len(parts)-1], depth: len(dirs)})
+	}
+	return rows
+}
+
+// filesUnder returns the files a row stands for: itself, or everything in
+// the directory.
+func (m Model) filesUnder(row reviewRow) []string {
+	if !row.dir {
+		return []string{row.path}
+	}
+	var paths []string
+	for _, f := range m.review {
+		if strings.HasPrefix(f.Path, row.path+"/") {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// toggleReview ticks or unticks the highlighted row. A directory that is
+// only partly ticked gets everything ticked.
+func (m *Model) toggleReview() {
+	rows := m.reviewRows()
+	if m.reviewCursor >= len(rows) {
+		return
+	}
+	paths := m.filesUnder(rows[m.reviewCursor])
+	if m.countSelected(paths) == len(paths) {
+		m.excluded = append(m.excluded, paths...)
+		return
+	}
+	m.excluded = slices.DeleteFunc(m.excluded, func(p string) bool { return slices.Contains(paths, p) })
+}
+
+// toggleAllReview ticks every file, or unticks every file when all are
+// ticked already.
+func (m *Model) toggleAllReview() {
+	if len(m.excluded) > 0 {
+		m.excluded = nil
+		return
+	}
+	for _, f := range m.review {
+		m.excluded = append(m.excluded, f.Path)
+	}
+}
+
+func (m Model) countSelected(paths []string) int {
+	n := 0
+	for _, p := range paths {
+		if !slices.Contains(m.excluded, p) {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *Model) moveReview(delta int) {
+	m.reviewCursor = max(0, min(m.reviewCursor+delta, len(m.reviewRows())-1))
+	m.showPreview()
+}
+
+// reviewSize is the screen area the tree and the preview share, falling
+// back to a classic terminal before the first WindowSizeMsg.
+func (m Model) reviewSize() (width, height int) {
+	width, height = m.windowWidth, m.windowHeight
+	if width == 0 || height == 0 {
+		width, height = 80, 24
+	}
+	// The outer margin, the title and the key help take the rest.
+	return max(width-4, 40), max(height-7, 5)
+}
+
+func (m Model) treeWidth() int {
+	width, _ := m.reviewSize()
+	widest := 0
+	for _, row := range m.reviewRows() {
+		widest = max(widest, 2*row.depth+len(row.name)+6)
+	}
+	return max(min(widest, width*2/5), 20)
+}
+
+func (m *Model) resizePreview() {
+	width, height := m.reviewSize()
+	// The preview has a border and a title line of its own.
+	m.preview.Width = max(width-m.treeWidth()-3, 10)
+	m.preview.Height = max(height-3, 1)
+}
+
+// showPreview puts the highlighted file into the preview pane, or a
+// summary when a directory is highlighted.
+func (m *Model) showPreview() {
+	rows := m.reviewRows()
+	if m.reviewCursor < 0 || m.reviewCursor >= len(rows) {
+		m.preview.SetContent("")
+		return
+	}
+	row := rows[m.reviewCursor]
+	if row.dir {
+		paths := m.filesUnder(row)
+		m.preview.SetContent(fmt.Sprintf("%d file(s), %d selected", len(paths), m.countSelected(paths)))
+		m.preview.GotoTop()
+		return
+	}
+
+	i := slices.IndexFunc(m.review, func(f scaffold.File) bool { return f.Path == row.path })
+	data := m.review[i].Data
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		m.preview.SetContent(fmt.Sprintf("binary file, %d bytes", len(data)))
+		m.preview.GotoTop()
+		return
+	}
+	lines := highlight(row.path, strings.TrimSuffix(string(data), "\n"))
+	gutter := len(fmt.Sprint(len(lines)))
+	for n, l := range lines {
+		lines[n] = commentStyle.Render(fmt.Sprintf("%*d ", gutter, n+1)) + l
+	}
+	m.preview.SetContent(strings.Join(lines, "\n"))
+	m.preview.GotoTop()
+}
+
+func (m Model) renderReview() string {
+	rows := m.reviewRows()
+	_, height := m.reviewSize()
+
+	// Keep the cursor in sight when the tree is taller than the screen.
+	top := max(0, m.reviewCursor-height+1)
+	var tree []string
+	for i := top; i < len(rows) && i < top+height; i++ {
+		row := rows[i]
+		box := "[x]"
+		if paths := m.filesUnder(row); m.countSelected(paths) == 0 {
+			box = "[ ]"
+		} else if row.dir && m.countSelected(paths) < len(paths) {
+			box = "[-]"
+		}
+		line := strings.Repeat("  ", row.depth) + box + " " + row.name
+		if r := []rune(line); len(r) > m.treeWidth() {
+			line = string(r[:m.treeWidth()-1]) + "…"
+		}
+		if i == m.reviewCursor {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("#00D7FF")).Render(line)
+		}
+		tree = append(tree, line)
+	}
+	treePane := lipgloss.NewStyle().Width(m.treeWidth()).MaxHeight(height).Render(strings.Join(tree, "\n"))
+
+	title := ""
+	if m.reviewCursor < len(rows) {
+		title = rows[m.reviewCursor].path
+		if rows[m.reviewCursor].dir {
+			title += "/"
+		} else if i := slices.IndexFunc(m.review, func(f scaffold.File) bool { return f.Path == title }); m.review[i].Mode&fs.ModeSymlink != 0 {
+			title += " (link)"
+		} else if m.review[i].Mode&0111 != 0 {
+			title += " (executable)"
+		}
+	}
+	previewPane := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		Render(commentStyle.MaxWidth(m.preview.Width).Render(title) + "\n" + m.preview.View())
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, treePane, " ", previewPane)
+}
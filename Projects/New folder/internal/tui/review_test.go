@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gen-code/internal/matrix"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func press(m Model, keys ...string) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(k)}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		}
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(Model)
+	}
+	return m, cmd
+}
+
+func TestReviewExcludesFiles(t *testing.T) {
+	m := InitialModel(Options{SkipHooks: true})
+	m.appName, m.selectedLang, m.selectedFW = "demo", matrix.Go, matrix.Gin
+	m.env, m.comp = matrix.Backend, matrix.Minimal
+	m.outputPath = t.TempDir()
+	if err := m.startReview(); err != nil {
+		t.Fatal(err)
+	}
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = next.(Model)
+
+	// Move the cursor down to main.go.
+	rows := m.reviewRows()
+	main := 0
+	for rows[main].path != "main.go" {
+		main++
+	}
+	for range main {
+		m, _ = press(m, "j")
+	}
+	if !strings.Contains(m.preview.View(), "package main") {
+		t.Fatalf("preview does not show main.go:\n%s", m.preview.View())
+	}
+	m, _ = press(m, " ")
+	if view := m.View(); !strings.Contains(view, "[ ] main.go") {
+		t.Fatalf("main.go not unticked:\n%s", view)
+	}
+
+	m, _ = press(m, "enter")
+	if m.state != stateConfirm || !strings.Contains(m.View(), "1 left out") {
+		t.Fatalf("state = %d:\n%s", m.state, m.View())
+	}
+	m, cmd := press(m, "enter")
+	next, _ = m.Update(cmd())
+	if m = next.(Model); m.state != stateDone {
+		t.Fatalf("generation failed: %v", m.err)
+	}
+	if _, err := os.Stat(filepath.Join(m.outputPath, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("unticked main.go was written")
+	}
+	if _, err := os.Stat(filepath.Join(m.outputPath, "go.mod")); err != nil {
+		t.Errorf("go.mod not written: %v", err)
+	}
+
+	// The answers are saved with the project, not in the working directory.
+	m, _ = press(m, "s")
+	saved := filepath.Join(m.outputPath, "demo.answers.yaml")
+	if _, err := os.Stat(saved); err != nil || !strings.Contains(m.notice, saved) {
+		t.Errorf("answers not saved to %s (%v): %s", saved, err, m.notice)
+	}
+}